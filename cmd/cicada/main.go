@@ -3,25 +3,269 @@ package main
 
 import (
 	"github.com/mcandre/cicada"
+	"gopkg.in/yaml.v3"
 
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
 )
 
-var flagQuiet = flag.Bool("quiet", false, "Skip system components unlikely to be actionable")
-var flagDebug = flag.Bool("debug", false, "Enable additional logging")
+var flagConfig = flag.String("config", "", "Configuration path (default: nearest cicada.yaml in the working directory or its parents)")
 var flagUpdate = flag.Bool("update", false, "Force LTS index cache update")
 var flagClean = flag.Bool("clean", false, "Remove cicada artifacts")
+var flagRoot = flag.String("root", "", "Scan an unpacked root filesystem instead of the live host")
+var flagBuildArgs = make(buildArgsFlag)
 var flagVersion = flag.Bool("version", false, "Show version information")
 var flagHelp = flag.Bool("help", false, "Show usage information")
 
+// settingFlag adapts a configuration setting to a command line flag.
+type settingFlag struct {
+	// setting denotes the configuration key.
+	setting cicada.Setting
+
+	// value denotes the raw flag value.
+	value string
+}
+
+// String renders the flag value.
+func (o *settingFlag) String() string {
+	if o == nil {
+		return ""
+	}
+
+	return o.value
+}
+
+// Set records the flag value.
+func (o *settingFlag) Set(value string) error {
+	o.value = value
+	return nil
+}
+
+// IsBoolFlag allows boolean settings to be supplied without a value.
+func (o *settingFlag) IsBoolFlag() bool {
+	return o.setting.Bool
+}
+
+// buildArgsFlag collects repeatable NAME=VALUE Dockerfile build arguments.
+//
+// A bare NAME takes its value from the environment, like docker build --build-arg.
+type buildArgsFlag map[string]string
+
+// String renders the flag value.
+func (o buildArgsFlag) String() string {
+	var pairs []string
+
+	for name, value := range o {
+		pairs = append(pairs, fmt.Sprintf("%v=%v", name, value))
+	}
+
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Set records a build argument.
+func (o buildArgsFlag) Set(arg string) error {
+	name, value, ok := strings.Cut(arg, "=")
+
+	if name == "" {
+		return fmt.Errorf("invalid build argument: %q", arg)
+	}
+
+	if !ok {
+		if value, ok = os.LookupEnv(name); !ok {
+			return nil
+		}
+	}
+
+	o[name] = value
+	return nil
+}
+
+// settingFlags denotes the setting overrides, keyed on flag name.
+var settingFlags = make(map[string]*settingFlag)
+
+func init() {
+	flag.Var(flagBuildArgs, "build-arg", "Override a Dockerfile ARG default, as NAME=VALUE (repeatable)")
+
+	for _, setting := range cicada.Settings {
+		f := settingFlag{setting: setting}
+		settingFlags[setting.Flag()] = &f
+		flag.Var(&f, setting.Flag(), fmt.Sprintf("%v (env: %v)", setting.Usage, setting.Env()))
+	}
+}
+
+// usage documents the command line interface.
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: cicada [OPTIONS] [init | doctor | config show | config validate | image scan <image.tar>]\n")
+	flag.PrintDefaults()
+}
+
+// loadOptions collects index loading parameters.
+func loadOptions() cicada.LoadOptions {
+	overrides := cicada.EnvironmentOverrides(os.Environ())
+
+	flag.Visit(func(f *flag.Flag) {
+		if sf, ok := settingFlags[f.Name]; ok {
+			overrides = append(overrides, cicada.Override{
+				Key:    sf.setting.Key,
+				Value:  sf.value,
+				Origin: fmt.Sprintf("flag -%v", f.Name),
+			})
+		}
+	})
+
+	return cicada.LoadOptions{
+		ConfigPath: *flagConfig,
+		Update:     *flagUpdate,
+		Overrides:  overrides,
+	}
+}
+
+// configShow prints the effective configuration.
+func configShow() {
+	index, err := cicada.LoadConfig(loadOptions())
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(index); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := encoder.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// configValidate checks the effective configuration.
+func configValidate() {
+	index, err := cicada.LoadConfig(loadOptions())
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err2 := index.LoadSchedules(); err2 != nil {
+		log.Fatal(err2)
+	}
+
+	warnings := index.Lint()
+
+	// Lint warnings are advisory, regardless of fail_threshold.
+	if err := index.Report(os.Stdout, warnings); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// report emits warnings, exiting non-zero when the scan fails.
+func report(index *cicada.Index, warnings []string) {
+	if err := index.Report(os.Stdout, warnings); err != nil {
+		log.Fatal(err)
+	}
+
+	if index.Failed(warnings) {
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+}
+
+// scanFailure aborts a scan, explaining any scan timeout.
+func scanFailure(ctx context.Context, index *cicada.Index, err error) {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		log.Fatalf("scan exceeded scan_timeout %v: %v", index.ScanTimeout, err)
+	}
+
+	log.Fatal(err)
+}
+
+// imageScan analyzes a container image tarball.
+func imageScan(pth string) {
+	index, err := cicada.Load(loadOptions())
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := index.WithScanTimeout(context.Background())
+	defer cancel()
+	warnings, err := index.ScanImage(ctx, pth)
+
+	if err != nil {
+		scanFailure(ctx, index, err)
+	}
+
+	report(index, warnings)
+}
+
+// doctor tabulates version query outcomes.
+func doctor() {
+	index, err := cicada.LoadConfig(loadOptions())
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := index.WithScanTimeout(context.Background())
+	defer cancel()
+	diagnoses, err := index.Doctor(ctx)
+
+	if err != nil {
+		scanFailure(ctx, index, err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	if _, err2 := fmt.Fprintln(w, "PRODUCT\tSTATUS\tVERSION\tDETAIL"); err2 != nil {
+		log.Fatal(err2)
+	}
+
+	for _, diagnosis := range diagnoses {
+		var version string
+
+		if diagnosis.Result.Version != nil {
+			version = diagnosis.Result.Version.String()
+		}
+
+		if _, err2 := fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", diagnosis.Product, diagnosis.Result.Status, version, diagnosis.Result.Detail()); err2 != nil {
+			log.Fatal(err2)
+		}
+	}
+
+	if err2 := w.Flush(); err2 != nil {
+		log.Fatal(err2)
+	}
+}
+
+// initConfig generates a tailored configuration.
+func initConfig() {
+	pth, err := cicada.Init()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("wrote %v\n", *pth)
+}
+
 func main() {
+	flag.Usage = usage
 	flag.Parse()
 
 	if *flagHelp {
-		flag.PrintDefaults()
+		usage()
 		os.Exit(0)
 	}
 
@@ -31,38 +275,66 @@ func main() {
 	}
 
 	if *flagClean {
-		if err := cicada.Clean(); err != nil {
+		if err := cicada.Clean(loadOptions()); err != nil {
 			log.Fatal(err)
 		}
 
 		os.Exit(0)
 	}
 
-	index, err := cicada.Load(*flagUpdate)
+	args := flag.Args()
+
+	switch {
+	case len(args) == 0:
+	case len(args) == 1 && args[0] == "init":
+		initConfig()
+		os.Exit(0)
+	case len(args) == 1 && args[0] == "doctor":
+		doctor()
+		os.Exit(0)
+	case len(args) == 2 && args[0] == "config" && args[1] == "show":
+		configShow()
+		os.Exit(0)
+	case len(args) == 2 && args[0] == "config" && args[1] == "validate":
+		configValidate()
+		os.Exit(0)
+	case len(args) == 3 && args[0] == "image" && args[1] == "scan":
+		imageScan(args[2])
+	default:
+		usage()
+		os.Exit(1)
+	}
+
+	index, err := cicada.Load(loadOptions())
 
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	if *flagDebug {
-		index.Debug = true
-	}
+	index.BuildArgs = flagBuildArgs
+	ctx, cancel := index.WithScanTimeout(context.Background())
+	defer cancel()
+	var warnings []string
 
-	if *flagQuiet {
-		index.Quiet = true
-	}
+	if *flagRoot != "" {
+		if _, err2 := os.Stat(*flagRoot); err2 != nil {
+			log.Fatal(err2)
+		}
 
-	warnings, err := index.Scan()
+		rootDir, err2 := filepath.Abs(*flagRoot)
 
-	if err != nil {
-		log.Fatal(err)
-	}
+		if err2 != nil {
+			log.Fatal(err2)
+		}
 
-	for _, warning := range warnings {
-		fmt.Printf("warning: %v\n", warning)
+		warnings, err = index.ScanRoot(ctx, cicada.Root{FS: os.DirFS(rootDir), Dir: rootDir})
+	} else {
+		warnings, err = index.Scan(ctx)
 	}
 
-	if len(warnings) > 0 {
-		os.Exit(1)
+	if err != nil {
+		scanFailure(ctx, index, err)
 	}
+
+	report(index, warnings)
 }
@@ -0,0 +1,171 @@
+package cicada
+
+import (
+	"gopkg.in/yaml.v3"
+
+	"bufio"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ComposeFilePattern matches Compose files,
+// such as docker-compose.yml, compose.yaml, and docker-compose.override.yml.
+var ComposeFilePattern = regexp.MustCompile(`^(docker-)?compose(\.[^.]+)?\.ya?ml$`)
+
+// DotEnvBase denotes the Compose variable defaults file,
+// relative to the Compose file directory.
+const DotEnvBase = ".env"
+
+// ParseDotEnv decodes KEY=VALUE lines.
+//
+// Blank lines, comments, export prefixes, and surrounding quotes are dropped.
+func ParseDotEnv(r io.Reader) (map[string]string, error) {
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+
+		if !ok {
+			continue
+		}
+
+		value = strings.TrimSpace(value)
+
+		switch {
+		case len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0]:
+			value = value[1 : len(value)-1]
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+
+		vars[strings.TrimSpace(key)] = value
+	}
+
+	return vars, scanner.Err()
+}
+
+// ExpandComposeVariables interpolates Compose variable references,
+// like ExpandDockerfileVariables, with $$ denoting a literal dollar sign.
+func ExpandComposeVariables(s string, vars map[string]string) string {
+	parts := strings.Split(s, "$$")
+
+	for i, part := range parts {
+		parts[i] = ExpandDockerfileVariables(part, vars)
+	}
+
+	return strings.Join(parts, "$")
+}
+
+// ParseCompose collects the services.*.image references of a Compose file.
+//
+// Services with a build section are skipped,
+// as their image names denote local build results.
+func ParseCompose(content []byte, vars map[string]string) ([]Image, error) {
+	var document yaml.Node
+
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+
+	if len(document.Content) == 0 {
+		return nil, nil
+	}
+
+	services := YAMLMappingValue(document.Content[0], "services")
+
+	if services == nil || services.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	var images []Image
+
+	for i := 1; i < len(services.Content); i += 2 {
+		service := services.Content[i]
+		imageNode := YAMLMappingValue(service, "image")
+
+		if imageNode == nil || imageNode.Kind != yaml.ScalarNode || YAMLMappingValue(service, "build") != nil {
+			continue
+		}
+
+		image := ParseImageReference(ExpandComposeVariables(imageNode.Value, vars))
+		image.Line = imageNode.Line
+
+		if image.Tag == "" && image.Digest == "" {
+			image.Tag = "latest"
+		}
+
+		if platformNode := YAMLMappingValue(service, "platform"); platformNode != nil {
+			image.Platform = ExpandComposeVariables(platformNode.Value, vars)
+		}
+
+		images = append(images, image)
+	}
+
+	return images, nil
+}
+
+// ExtractComposeImages collects the image references of a Compose file path.
+//
+// Variables resolve against the process environment,
+// then any .env file beside the Compose file.
+//
+// A false result indicates a file other than a Compose file.
+// Malformed Compose and .env files are logged and skipped.
+func ExtractComposeImages(pth string) ([]Image, bool, error) {
+	if !ComposeFilePattern.MatchString(filepath.Base(pth)) {
+		return nil, false, nil
+	}
+
+	content, err := os.ReadFile(pth)
+
+	if err != nil {
+		return nil, true, err
+	}
+
+	vars := make(map[string]string)
+	f, err := os.Open(filepath.Join(filepath.Dir(pth), DotEnvBase))
+
+	if err == nil {
+		vars, err = ParseDotEnv(f)
+
+		if err2 := f.Close(); err2 != nil {
+			return nil, true, err2
+		}
+
+		if err != nil {
+			log.Printf("skipping compose file with malformed %v: %v: %v\n", DotEnvBase, pth, err)
+			return nil, false, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, true, err
+	}
+
+	for _, entry := range os.Environ() {
+		if key, value, ok := strings.Cut(entry, "="); ok {
+			vars[key] = value
+		}
+	}
+
+	images, err := ParseCompose(content, vars)
+
+	if err != nil {
+		log.Printf("skipping malformed compose file: %v: %v\n", pth, err)
+		return nil, false, nil
+	}
+
+	return images, true, nil
+}
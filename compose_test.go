@@ -0,0 +1,86 @@
+package cicada_test
+
+import (
+	"github.com/mcandre/cicada"
+
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	vars, err := cicada.ParseDotEnv(strings.NewReader("# versions\nexport POSTGRES_TAG=11\nREDIS_TAG=\"5.0\"\nNGINX_TAG=1.20 # stable\n"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if vars["POSTGRES_TAG"] != "11" || vars["REDIS_TAG"] != "5.0" || vars["NGINX_TAG"] != "1.20" {
+		t.Errorf("Expected .env variables, got: %v", vars)
+	}
+}
+
+func TestParseCompose(t *testing.T) {
+	content := []byte(`services:
+  db:
+    image: postgres:${POSTGRES_TAG:-13}
+  cache:
+    image: "redis:$REDIS_TAG"
+    platform: linux/arm64
+  app:
+    build: .
+    image: example/app:dev
+  proxy:
+    image: nginx
+    command: ["sh", "-c", "echo $$HOME"]
+`)
+
+	images, err := cicada.ParseCompose(content, map[string]string{"REDIS_TAG": "5"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []cicada.Image{
+		{Name: "postgres", Tag: "13", Line: 3},
+		{Name: "redis", Tag: "5", Platform: "linux/arm64", Line: 5},
+		{Name: "nginx", Tag: "latest", Line: 11},
+	}
+
+	if len(images) != len(expected) {
+		t.Fatalf("Expected %v, got: %v", expected, images)
+	}
+
+	for i, image := range images {
+		if image != expected[i] {
+			t.Errorf("Expected %#v, got: %#v", expected[i], image)
+		}
+	}
+
+	if actual := cicada.ExpandComposeVariables("$$HOME:${TAG}", map[string]string{"TAG": "1"}); actual != "$HOME:1" {
+		t.Errorf("Expected escaped dollar sign, got: %q", actual)
+	}
+}
+
+func TestScanComposeFilesSkipsMalformed(t *testing.T) {
+	index := loadProject(t, map[string]string{
+		"lc/postgresql.yaml":      "- version: \"9.6\"\n  expiration: \"2021-11-11\"\n",
+		"bad/docker-compose.yml":  "services:\n  db:\n    image: [postgres\n",
+		"good/docker-compose.yml": "services:\n  db:\n    image: postgres:9.6\n",
+	})
+
+	warnings, err := index.ScanComposeFiles(context.Background(), time.Now())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"end of life for postgresql 9.6.0 on 2021-11-11 at " + filepath.Join("good", "docker-compose.yml") + ":3"}
+
+	if !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("Expected %v, got: %v", expected, warnings)
+	}
+}
@@ -0,0 +1,188 @@
+package cicada
+
+import (
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ConfigBases enumerates the recognized configuration file names,
+// in descending precedence.
+var ConfigBases = []string{
+	"cicada.yaml",
+	"cicada.json",
+	"cicada.toml",
+	".cicada.yaml",
+	".cicada.json",
+	".cicada.toml",
+}
+
+// ConfigFormatYAML denotes YAML configurations.
+const ConfigFormatYAML = "yaml"
+
+// ConfigFormatJSON denotes JSON configurations.
+const ConfigFormatJSON = "json"
+
+// ConfigFormatTOML denotes TOML configurations.
+const ConfigFormatTOML = "toml"
+
+// ConfigFormat identifies the format of a configuration path or URL,
+// by file extension.
+//
+// Unrecognized extensions are treated as YAML.
+func ConfigFormat(location string) string {
+	pth := location
+
+	if IsURL(location) {
+		if u, err := url.Parse(location); err == nil {
+			pth = u.Path
+		}
+	}
+
+	switch strings.ToLower(path.Ext(pth)) {
+	case ".json":
+		return ConfigFormatJSON
+	case ".toml":
+		return ConfigFormatTOML
+	default:
+		return ConfigFormatYAML
+	}
+}
+
+// DecodeJSONStrict decodes a JSON document,
+// rejecting any unknown fields.
+func DecodeJSONStrict(contentJSON []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(contentJSON))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	return nil
+}
+
+// DecodeTOMLStrict decodes a TOML document,
+// rejecting any unknown fields.
+func DecodeTOMLStrict(contentTOML []byte, v interface{}) error {
+	metadata, err := toml.Decode(string(contentTOML), v)
+
+	if err != nil {
+		return err
+	}
+
+	if undecoded := metadata.Undecoded(); len(undecoded) != 0 {
+		return fmt.Errorf("field %v not found", undecoded[0])
+	}
+
+	return nil
+}
+
+// DecodeConfig decodes a configuration document strictly,
+// according to the format of its location.
+func DecodeConfig(location string, content []byte, v interface{}) error {
+	switch ConfigFormat(location) {
+	case ConfigFormatJSON:
+		return DecodeJSONStrict(content, v)
+	case ConfigFormatTOML:
+		return DecodeTOMLStrict(content, v)
+	default:
+		return DecodeYAMLStrict(content, v)
+	}
+}
+
+// DecodeConfigKeys collects the top level keys of a configuration document.
+func DecodeConfigKeys(location string, content []byte) ([]string, error) {
+	var table map[string]interface{}
+	var err error
+
+	switch ConfigFormat(location) {
+	case ConfigFormatJSON:
+		if len(bytes.TrimSpace(content)) != 0 {
+			err = json.Unmarshal(content, &table)
+		}
+	case ConfigFormatTOML:
+		_, err = toml.Decode(string(content), &table)
+	default:
+		err = yaml.Unmarshal(content, &table)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+
+	for key := range table {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// MarshalTOMLInline encodes a flat structure as a TOML inline table,
+// honoring its json struct tags.
+func MarshalTOMLInline(v interface{}) ([]byte, error) {
+	contentJSON, err := json.Marshal(v)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var table map[string]interface{}
+
+	if err2 := json.Unmarshal(contentJSON, &table); err2 != nil {
+		return nil, err2
+	}
+
+	var keys []string
+
+	for key := range table {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var pairs []string
+
+	for _, key := range keys {
+		// JSON strings and arrays of JSON strings are valid TOML values.
+		valueJSON, err2 := json.Marshal(table[key])
+
+		if err2 != nil {
+			return nil, err2
+		}
+
+		value := string(valueJSON)
+
+		if elements, ok := table[key].([]interface{}); ok {
+			var items []string
+
+			for _, element := range elements {
+				elementJSON, err3 := json.Marshal(element)
+
+				if err3 != nil {
+					return nil, err3
+				}
+
+				items = append(items, string(elementJSON))
+			}
+
+			value = fmt.Sprintf("[%v]", strings.Join(items, ", "))
+		}
+
+		pairs = append(pairs, fmt.Sprintf("%v = %v", key, value))
+	}
+
+	return []byte(fmt.Sprintf("{%v}", strings.Join(pairs, ", "))), nil
+}
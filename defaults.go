@@ -0,0 +1,32 @@
+package cicada
+
+import (
+	_ "embed"
+)
+
+// DefaultConfigYAML denotes the built-in configuration,
+// which supplies version queries for many endoflife.date products.
+//
+// Project configurations merge over the defaults.
+//
+//go:embed example/cicada.yaml
+var DefaultConfigYAML []byte
+
+// NewDefaultIndex constructs an index from the built-in configuration.
+func NewDefaultIndex() (*Index, error) {
+	index := new(Index)
+	index.LeadMonths = DefaultLeadMonths
+	index.CacheDir = IndexCacheRoot
+	index.Format = FormatText
+	index.FailThreshold = DefaultFailThreshold
+	index.QueryTimeout = DefaultQueryTimeout
+	index.ScanTimeout = DefaultScanTimeout
+	index.Jobs = DefaultJobs
+	index.Packages = true
+
+	if err := DecodeYAMLStrict(DefaultConfigYAML, index); err != nil {
+		return nil, err
+	}
+
+	return index, nil
+}
@@ -0,0 +1,107 @@
+package cicada
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DirectorySourceExtensions enumerates schedule file extensions,
+// in descending precedence.
+var DirectorySourceExtensions = []string{".yaml", ".yml", ".json", ".toml"}
+
+// DirectorySource reads LTS schedules from a local directory.
+//
+// Each component is stored as an array of schedules,
+// in a YAML, JSON, or TOML file named after the component.
+//
+// TOML files nest the array under a "schedules" key.
+//
+// For example:
+//
+//	# widget.yaml
+//	- version: "2.1"
+//	  codename: bolt
+//	  expiration: "2030-01-31"
+type DirectorySource struct {
+	// Path denotes the schedules directory.
+	Path string
+}
+
+// Products lists the component names in the directory.
+func (o DirectorySource) Products() ([]string, error) {
+	entries, err := os.ReadDir(o.Path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var products []string
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		name := entry.Name()
+		extension := filepath.Ext(name)
+
+		for _, e := range DirectorySourceExtensions {
+			if extension == e {
+				products = append(products, strings.TrimSuffix(name, extension))
+				break
+			}
+		}
+	}
+
+	return products, nil
+}
+
+// Schedules fetches the support timelines of a component.
+func (o DirectorySource) Schedules(product string) ([]Schedule, error) {
+	var pth string
+
+	for _, extension := range DirectorySourceExtensions {
+		candidate := filepath.Join(o.Path, product+extension)
+
+		if _, err := os.Stat(candidate); err == nil {
+			pth = candidate
+			break
+		}
+	}
+
+	if pth == "" {
+		return nil, fmt.Errorf("unknown product: %v", product)
+	}
+
+	content, err := os.ReadFile(pth)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var schedules []Schedule
+
+	if ConfigFormat(pth) == ConfigFormatTOML {
+		var document struct {
+			Schedules []Schedule `toml:"schedules"`
+		}
+
+		if err2 := DecodeTOMLStrict(content, &document); err2 != nil {
+			return nil, fmt.Errorf("%v: %v", pth, err2)
+		}
+
+		schedules = document.Schedules
+	} else if err2 := DecodeConfig(pth, content, &schedules); err2 != nil {
+		return nil, fmt.Errorf("%v: %v", pth, err2)
+	}
+
+	for i := range schedules {
+		if schedules[i].Name == "" {
+			schedules[i].Name = product
+		}
+	}
+
+	return schedules, nil
+}
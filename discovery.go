@@ -0,0 +1,111 @@
+package cicada
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// RepositoryMarker denotes a version control directory,
+// which bounds configuration discovery.
+const RepositoryMarker = ".git"
+
+// FindConfigInDir locates the highest precedence configuration file in dir.
+//
+// Returns nil when dir contains no configuration.
+func FindConfigInDir(dir string) (*string, error) {
+	for _, base := range ConfigBases {
+		pth := filepath.Join(dir, base)
+
+		if _, err := os.Stat(pth); err == nil {
+			return &pth, nil
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// FindConfig searches dir and its parent directories
+// for the nearest cicada configuration.
+//
+// The search stops at the repository root or the filesystem root.
+//
+// Returns nil when no configuration is found.
+func FindConfig(dir string) (*string, error) {
+	dir, err := filepath.Abs(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		pthP, err := FindConfigInDir(dir)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if pthP != nil {
+			return pthP, nil
+		}
+
+		if _, err2 := os.Stat(filepath.Join(dir, RepositoryMarker)); err2 == nil {
+			return nil, nil
+		}
+
+		parent := filepath.Dir(dir)
+
+		if parent == dir {
+			return nil, nil
+		}
+
+		dir = parent
+	}
+}
+
+// LocateConfig resolves the configuration path and project directory.
+//
+// An explicit configPath must exist.
+// Otherwise, the configuration is discovered from the current working directory.
+//
+// The project directory is the configuration's directory,
+// or the current working directory when no configuration is found.
+//
+// Returns a nil configuration path when no configuration is found.
+func LocateConfig(configPath string) (*string, *string, error) {
+	if configPath != "" {
+		if _, err := os.Stat(configPath); err != nil {
+			return nil, nil, fmt.Errorf("missing configuration: %v", configPath)
+		}
+
+		pth, err := filepath.Abs(configPath)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		dir := filepath.Dir(pth)
+		return &pth, &dir, nil
+	}
+
+	cwd, err := os.Getwd()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pthP, err := FindConfig(cwd)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if pthP == nil {
+		return nil, &cwd, nil
+	}
+
+	dir := filepath.Dir(*pthP)
+	return pthP, &dir, nil
+}
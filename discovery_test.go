@@ -0,0 +1,64 @@
+package cicada_test
+
+import (
+	"github.com/mcandre/cicada"
+
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindConfig(t *testing.T) {
+	dir := t.TempDir()
+	subdir := filepath.Join(dir, "repo", "services", "api")
+
+	if err := os.MkdirAll(subdir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	outerConfig := filepath.Join(dir, cicada.IndexCacheBase)
+
+	if err := os.WriteFile(outerConfig, []byte("---\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pth, err := cicada.FindConfig(subdir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pth == nil || *pth != outerConfig {
+		t.Errorf("Expected configuration %v, got: %v", outerConfig, pth)
+	}
+
+	if err := os.Mkdir(filepath.Join(dir, "repo", cicada.RepositoryMarker), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	pth, err = cicada.FindConfig(subdir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pth != nil {
+		t.Errorf("Expected discovery to stop at repository root, got: %v", *pth)
+	}
+
+	innerConfig := filepath.Join(dir, "repo", "services", cicada.IndexCacheBase)
+
+	if err := os.WriteFile(innerConfig, []byte("---\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pth, err = cicada.FindConfig(subdir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pth == nil || *pth != innerConfig {
+		t.Errorf("Expected configuration %v, got: %v", innerConfig, pth)
+	}
+}
@@ -0,0 +1,283 @@
+package cicada
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// DockerfileParserDirectivePattern matches parser directives,
+// such as # escape=`
+var DockerfileParserDirectivePattern = regexp.MustCompile(`^#\s*(?P<Key>[A-Za-z]+)\s*=\s*(?P<Value>\S+)\s*$`)
+
+// DockerfileInstruction models a logical Dockerfile instruction,
+// with any line continuations joined.
+type DockerfileInstruction struct {
+	// Command denotes the upper case instruction keyword, such as FROM.
+	Command string
+
+	// Value denotes the instruction arguments.
+	Value string
+
+	// Line denotes the one-based line number where the instruction begins.
+	Line int
+}
+
+// ParseDockerfile splits a Dockerfile into instructions.
+//
+// Comments are dropped, including comment lines within continuations.
+// The escape parser directive selects the line continuation character.
+func ParseDockerfile(r io.Reader) ([]DockerfileInstruction, error) {
+	var instructions []DockerfileInstruction
+	escape := `\`
+	directives := true
+	var logical strings.Builder
+	var start int
+	var lineNumber int
+
+	flush := func() {
+		fields := strings.Fields(logical.String())
+
+		if len(fields) != 0 {
+			instructions = append(instructions, DockerfileInstruction{
+				Command: strings.ToUpper(fields[0]),
+				Value:   strings.Join(fields[1:], " "),
+				Line:    start,
+			})
+		}
+
+		logical.Reset()
+		start = 0
+	}
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+
+		if directives {
+			if match := DockerfileParserDirectivePattern.FindStringSubmatch(line); match != nil {
+				key := match[DockerfileParserDirectivePattern.SubexpIndex("Key")]
+				value := match[DockerfileParserDirectivePattern.SubexpIndex("Value")]
+
+				if strings.EqualFold(key, "escape") && (value == "`" || value == `\`) {
+					escape = value
+				}
+
+				continue
+			}
+
+			directives = false
+		}
+
+		trimmed := strings.TrimSpace(line)
+
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if start == 0 {
+			start = lineNumber
+		}
+
+		if strings.HasSuffix(line, escape) {
+			logical.WriteString(strings.TrimSuffix(line, escape))
+			logical.WriteString(" ")
+			continue
+		}
+
+		logical.WriteString(line)
+		flush()
+	}
+
+	if start != 0 {
+		flush()
+	}
+
+	return instructions, scanner.Err()
+}
+
+// SplitDockerfileWords splits instruction arguments on whitespace,
+// honoring and removing single and double quotes.
+func SplitDockerfileWords(value string) []string {
+	var words []string
+	var word strings.Builder
+	var quote rune
+	var inWord bool
+
+	for _, c := range value {
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(c)
+		case c == '"' || c == '\'':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words
+}
+
+// ExpandDockerfileVariables substitutes $NAME and ${NAME} references,
+// including the ${NAME:-default} and ${NAME:+alternate} modifiers.
+//
+// Unset variables expand to blank.
+// ${NAME:?message} references expand like plain references.
+func ExpandDockerfileVariables(s string, vars map[string]string) string {
+	return os.Expand(s, func(reference string) string {
+		for _, modifier := range []string{":-", ":+", ":?", "-", "+", "?"} {
+			name, word, ok := strings.Cut(reference, modifier)
+
+			if !ok {
+				continue
+			}
+
+			value, set := vars[name]
+
+			switch modifier {
+			case ":-":
+				if value == "" {
+					return word
+				}
+			case ":+":
+				if value != "" {
+					return word
+				}
+
+				return ""
+			case "-":
+				if !set {
+					return word
+				}
+			case "+":
+				if set {
+					return word
+				}
+
+				return ""
+			}
+
+			return value
+		}
+
+		return vars[reference]
+	})
+}
+
+// ParseImageReference decomposes a Docker image reference,
+// such as registry.example.com:5000/team/app:1.2@sha256:abc.
+//
+// The registry denotes any path components preceding the image name.
+func ParseImageReference(reference string) Image {
+	var image Image
+	reference, image.Digest, _ = strings.Cut(reference, "@")
+
+	if i := strings.LastIndex(reference, "/"); i >= 0 {
+		image.Registry = reference[:i]
+		reference = reference[i+1:]
+	}
+
+	image.Name, image.Tag, _ = strings.Cut(reference, ":")
+	return image
+}
+
+// DockerfileBaseImages collects the base images of Dockerfile instructions,
+// excluding references to earlier build stages.
+//
+// ARG instructions preceding the first FROM supply defaults for variables in FROM lines.
+// buildArgs overrides the defaults of declared ARGs, like docker build --build-arg.
+func DockerfileBaseImages(instructions []DockerfileInstruction, buildArgs map[string]string) ([]Image, error) {
+	vars := make(map[string]string)
+	var images []Image
+	stages := make(map[string]bool)
+	var staged bool
+
+	for _, instruction := range instructions {
+		switch instruction.Command {
+		case "ARG":
+			if staged {
+				continue
+			}
+
+			for _, word := range SplitDockerfileWords(instruction.Value) {
+				name, value, _ := strings.Cut(word, "=")
+
+				if override, ok := buildArgs[name]; ok {
+					value = override
+				} else {
+					value = ExpandDockerfileVariables(value, vars)
+				}
+
+				vars[name] = value
+			}
+		case "FROM":
+			staged = true
+			var platform string
+			var words []string
+
+			for _, word := range strings.Fields(instruction.Value) {
+				if strings.HasPrefix(word, "--") {
+					flagName, flagValue, _ := strings.Cut(strings.TrimPrefix(word, "--"), "=")
+
+					if flagName == "platform" {
+						platform = ExpandDockerfileVariables(flagValue, vars)
+					}
+
+					continue
+				}
+
+				words = append(words, word)
+			}
+
+			if len(words) == 0 {
+				return nil, fmt.Errorf("line %d: FROM missing image", instruction.Line)
+			}
+
+			reference := ExpandDockerfileVariables(words[0], vars)
+
+			if stages[strings.ToLower(reference)] {
+				if len(words) == 3 && strings.EqualFold(words[1], "as") {
+					stages[strings.ToLower(words[2])] = true
+				}
+
+				continue
+			}
+
+			image := ParseImageReference(reference)
+			image.Platform = platform
+			image.Line = instruction.Line
+
+			if image.Tag == "" && image.Digest == "" {
+				image.Tag = "latest"
+			}
+
+			if len(words) == 3 && strings.EqualFold(words[1], "as") {
+				image.Stage = words[2]
+				stages[strings.ToLower(words[2])] = true
+			}
+
+			images = append(images, image)
+		}
+	}
+
+	return images, nil
+}
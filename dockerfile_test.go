@@ -0,0 +1,79 @@
+package cicada_test
+
+import (
+	"github.com/mcandre/cicada"
+
+	"strings"
+	"testing"
+)
+
+func TestDockerfileBaseImages(t *testing.T) {
+	content := `# syntax=docker/dockerfile:1
+ARG BASE_TAG=3.16
+ARG REGISTRY
+
+from --platform=$BUILDPLATFORM node:18 AS build
+RUN npm ci \
+    # comment within continuation
+    && npm run build
+
+FROM \
+    ${REGISTRY:-registry.example.com:5000}/alpine:${BASE_TAG}
+COPY --from=build /app /app
+
+FROM build AS test
+
+FROM ubuntu@sha256:0123456789abcdef
+FROM debian
+`
+
+	instructions, err := cicada.ParseDockerfile(strings.NewReader(content))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(instructions) != 9 || instructions[3].Command != "RUN" || instructions[3].Line != 6 || instructions[3].Value != "npm ci && npm run build" {
+		t.Errorf("Expected joined RUN continuation on line 6, got: %v", instructions)
+	}
+
+	images, err := cicada.DockerfileBaseImages(instructions, map[string]string{"BASE_TAG": "3.15"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []cicada.Image{
+		{Name: "node", Tag: "18", Stage: "build", Line: 5},
+		{Registry: "registry.example.com:5000", Name: "alpine", Tag: "3.15", Line: 10},
+		{Name: "ubuntu", Digest: "sha256:0123456789abcdef", Line: 16},
+		{Name: "debian", Tag: "latest", Line: 17},
+	}
+
+	if len(images) != len(expected) {
+		t.Fatalf("Expected %v, got: %v", expected, images)
+	}
+
+	for i, image := range images {
+		if image != expected[i] {
+			t.Errorf("Expected %#v, got: %#v", expected[i], image)
+		}
+	}
+}
+
+func TestExpandDockerfileVariables(t *testing.T) {
+	vars := map[string]string{"TAG": "3.16", "EMPTY": ""}
+
+	for s, expected := range map[string]string{
+		"alpine:$TAG":             "alpine:3.16",
+		"alpine:${TAG}":           "alpine:3.16",
+		"alpine:${MISSING:-3.15}": "alpine:3.15",
+		"alpine:${EMPTY:-3.15}":   "alpine:3.15",
+		"alpine:${EMPTY-3.15}":    "alpine:",
+		"alpine${TAG:+-edge}":     "alpine-edge",
+	} {
+		if actual := cicada.ExpandDockerfileVariables(s, vars); actual != expected {
+			t.Errorf("Expected %q to expand to %q, got: %q", s, expected, actual)
+		}
+	}
+}
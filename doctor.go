@@ -0,0 +1,60 @@
+package cicada
+
+import (
+	"context"
+	"sort"
+)
+
+// Diagnosis models the outcome of a configured version query.
+type Diagnosis struct {
+	// Product denotes the component name.
+	Product string
+
+	// Query denotes the version query.
+	Query VersionQuery
+
+	// Result denotes the query outcome.
+	Result QueryResult
+}
+
+// Doctor runs every configured version query,
+// classifying each outcome for troubleshooting.
+//
+// Up to Jobs queries run concurrently.
+func (o Index) Doctor(ctx context.Context) ([]Diagnosis, error) {
+	var products []string
+
+	for product := range o.VersionQueries {
+		products = append(products, product)
+	}
+
+	sort.Strings(products)
+
+	diagnoses := make([]Diagnosis, len(products))
+	errs := make([]error, len(products))
+
+	RunJobs(len(products), o.Jobs, func(i int) {
+		product := products[i]
+		query := o.VersionQueries[product]
+		result, err := query.Execute(ctx, o.QueryTimeout)
+
+		if err != nil {
+			errs[i] = err
+			return
+		}
+
+		diagnoses[i] = Diagnosis{
+			Product: product,
+			Query:   query,
+			Result:  *result,
+		}
+	})
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return diagnoses, nil
+}
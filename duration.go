@@ -0,0 +1,42 @@
+package cicada
+
+import (
+	"time"
+)
+
+// DefaultQueryTimeout bounds each version query,
+// so that a hanging command does not freeze the scan.
+const DefaultQueryTimeout = Duration(10 * time.Second)
+
+// DefaultScanTimeout bounds each scan as a whole,
+// so that many slow queries or registry reads do not stall automation.
+const DefaultScanTimeout = Duration(10 * time.Minute)
+
+// DefaultJobs denotes the number of application version queries run concurrently.
+const DefaultJobs = 4
+
+// Duration models time spans serialized in time.ParseDuration format,
+// such as "10s", across YAML, JSON, and TOML.
+type Duration time.Duration
+
+// String renders durations.
+func (o Duration) String() string {
+	return time.Duration(o).String()
+}
+
+// MarshalText encodes durations.
+func (o Duration) MarshalText() ([]byte, error) {
+	return []byte(o.String()), nil
+}
+
+// UnmarshalText decodes durations.
+func (o *Duration) UnmarshalText(text []byte) error {
+	d, err := time.ParseDuration(string(text))
+
+	if err != nil {
+		return err
+	}
+
+	*o = Duration(d)
+	return nil
+}
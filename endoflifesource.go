@@ -0,0 +1,110 @@
+package cicada
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path"
+)
+
+// CacheLifetimeData ensures a local copy of endoflife.date records.
+func CacheLifetimeData(indexProductsListFilePath string, indexProductsDirPath string) error {
+	log.Println("Caching new product data...")
+
+	body, err := HTTPGet(fmt.Sprintf("%v/%v", EndOfLifeBaseURL, ProductsListResourceBase))
+
+	if err != nil {
+		return err
+	}
+
+	var products []string
+	if err2 := json.Unmarshal(body, &products); err2 != nil {
+		return err2
+	}
+
+	if err2 := os.MkdirAll(indexProductsDirPath, os.ModePerm); err2 != nil {
+		return err2
+	}
+
+	for _, product := range products {
+		productBase := fmt.Sprintf("%v.json", product)
+		productBody, err2 := HTTPGet(fmt.Sprintf("%v/%v", EndOfLifeBaseURL, productBase))
+
+		if err2 != nil {
+			return err2
+		}
+
+		if err3 := os.WriteFile(path.Join(indexProductsDirPath, productBase), productBody, 0644); err3 != nil {
+			return err3
+		}
+	}
+
+	// Write the products list last, so that interrupted downloads are retried.
+	return os.WriteFile(indexProductsListFilePath, body, 0644)
+}
+
+// EndOfLifeSource reads LTS schedules from a local copy of endoflife.date records.
+type EndOfLifeSource struct {
+	// ProductsListPath denotes the cached products list.
+	ProductsListPath string
+
+	// ProductsDirPath denotes the cached product details directory.
+	ProductsDirPath string
+}
+
+// NewEndOfLifeSource constructs an EndOfLifeSource,
+// caching endoflife.date records according to the cache policy.
+func NewEndOfLifeSource(policy CachePolicy) (*EndOfLifeSource, error) {
+	source := EndOfLifeSource{
+		ProductsListPath: path.Join(policy.Dir, IndexProductsListBase),
+		ProductsDirPath:  path.Join(policy.Dir, IndexProductsDirBase),
+	}
+
+	refresh, err := policy.Refresh(source.ProductsListPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if refresh {
+		if err2 := CacheLifetimeData(source.ProductsListPath, source.ProductsDirPath); err2 != nil {
+			return nil, err2
+		}
+	}
+
+	return &source, nil
+}
+
+// Products lists the cached endoflife.date products.
+func (o EndOfLifeSource) Products() ([]string, error) {
+	productListBuf, err := os.ReadFile(o.ProductsListPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var products []string
+	if err2 := json.Unmarshal(productListBuf, &products); err2 != nil {
+		return nil, err2
+	}
+
+	return products, nil
+}
+
+// Schedules fetches the support timelines of an endoflife.date product.
+func (o EndOfLifeSource) Schedules(product string) ([]Schedule, error) {
+	productDetailPath := fmt.Sprintf("%v.json", path.Join(o.ProductsDirPath, product))
+	productDetailBuf, err := os.ReadFile(productDetailPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var records ProductRecords
+	if err2 := json.Unmarshal(productDetailBuf, &records); err2 != nil {
+		return nil, err2
+	}
+
+	return ProductRecordsToSchedules(product, records)
+}
@@ -0,0 +1,169 @@
+package cicada
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IndexExtendsDirBase denotes the base path of cached remote configurations,
+// relative to IndexCacheRoot.
+const IndexExtendsDirBase = "extends"
+
+// IsURL reports whether a configuration location denotes a remote resource.
+func IsURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// CacheConfig ensures a local copy of a remote configuration,
+// according to the cache policy.
+func CacheConfig(u string, policy CachePolicy) (*string, error) {
+	digest := sha256.Sum256([]byte(u))
+	cacheBase := fmt.Sprintf("%v.%v", hex.EncodeToString(digest[:8]), ConfigFormat(u))
+	pth := path.Join(policy.Dir, IndexExtendsDirBase, cacheBase)
+	refresh, err := policy.Refresh(pth)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !refresh {
+		return &pth, nil
+	}
+
+	body, err := HTTPGet(u)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err2 := os.MkdirAll(filepath.Dir(pth), os.ModePerm); err2 != nil {
+		return nil, err2
+	}
+
+	if err2 := os.WriteFile(pth, body, 0644); err2 != nil {
+		return nil, err2
+	}
+
+	return &pth, nil
+}
+
+// ResolveExtends locates an extends entry relative to the configuration that declares it.
+func ResolveExtends(parent string, entry string) (*string, error) {
+	if IsURL(entry) || filepath.IsAbs(entry) {
+		return &entry, nil
+	}
+
+	if IsURL(parent) {
+		base, err := url.Parse(parent)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ref, err := url.Parse(entry)
+
+		if err != nil {
+			return nil, err
+		}
+
+		location := base.ResolveReference(ref).String()
+		return &location, nil
+	}
+
+	location := filepath.Join(filepath.Dir(parent), entry)
+	return &location, nil
+}
+
+// Merge layers a configuration onto the index.
+//
+// Any extends entries in the configuration are merged first,
+// so that the configuration's own keys take precedence over inherited keys.
+//
+// location denotes a local path or URL.
+//
+// policy controls caching of remote configurations.
+//
+// ancestors denotes the chain of extending configurations, for cycle detection.
+func (o *Index) Merge(location string, policy CachePolicy, ancestors []string) error {
+	for _, ancestor := range ancestors {
+		if ancestor == location {
+			return fmt.Errorf("configuration extends cycle: %v", strings.Join(append(ancestors, location), " -> "))
+		}
+	}
+
+	pth := location
+
+	if IsURL(location) {
+		pthP, err := CacheConfig(location, policy)
+
+		if err != nil {
+			return err
+		}
+
+		pth = *pthP
+	}
+
+	content, err := os.ReadFile(pth)
+
+	if err != nil {
+		return err
+	}
+
+	var layer Index
+
+	if err2 := DecodeConfig(location, content, &layer); err2 != nil {
+		return fmt.Errorf("%v: %v", location, err2)
+	}
+
+	for _, entry := range layer.Extends {
+		parentLocation, err2 := ResolveExtends(location, entry)
+
+		if err2 != nil {
+			return err2
+		}
+
+		if err3 := o.Merge(*parentLocation, policy, append(ancestors, location)); err3 != nil {
+			return err3
+		}
+	}
+
+	if err2 := DecodeConfig(location, content, o); err2 != nil {
+		return fmt.Errorf("%v: %v", location, err2)
+	}
+
+	if o.queryLocations == nil {
+		o.queryLocations = make(map[string]string)
+	}
+
+	for product := range layer.VersionQueries {
+		o.queryLocations[product] = location
+	}
+
+	keys, err := DecodeConfigKeys(location, content)
+
+	if err != nil {
+		return fmt.Errorf("%v: %v", location, err)
+	}
+
+	for _, key := range keys {
+		if IsSetting(key) {
+			o.setOrigin(key, location)
+		}
+	}
+
+	if len(layer.Sources) != 0 && !IsURL(location) {
+		for i, source := range o.Sources {
+			if source.Kind == SourceKindDirectory && !filepath.IsAbs(source.Path) {
+				o.Sources[i].Path = filepath.Join(filepath.Dir(location), source.Path)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package cicada_test
+
+import (
+	"github.com/mcandre/cicada"
+
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIndexMergeExtends(t *testing.T) {
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base", "cicada.yaml")
+	projectPath := filepath.Join(dir, "project", "cicada.yaml")
+
+	if err := os.MkdirAll(filepath.Dir(basePath), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(projectPath), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	baseYAML := `---
+lead_months: 3
+version_queries:
+  go:
+    command: ["go", "version"]
+  ruby:
+    command: ["ruby", "-v"]
+`
+
+	projectYAML := `---
+extends: ["../base/cicada.yaml"]
+version_queries:
+  ruby:
+    command: ["ruby", "--version"]
+`
+
+	if err := os.WriteFile(basePath, []byte(baseYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(projectPath, []byte(projectYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var index cicada.Index
+
+	if err := index.Merge(projectPath, cicada.CachePolicy{Dir: dir}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if index.LeadMonths != 3 {
+		t.Errorf("Expected inherited lead_months 3, got: %v", index.LeadMonths)
+	}
+
+	if len(index.VersionQueries) != 2 {
+		t.Errorf("Expected merged version queries, got: %v", index.VersionQueries)
+	}
+
+	if command := index.VersionQueries["ruby"].Command; len(command) != 2 || command[1] != "--version" {
+		t.Errorf("Expected project ruby query to take precedence, got: %v", command)
+	}
+}
+
+func TestIndexMergeExtendsCycle(t *testing.T) {
+	dir := t.TempDir()
+	pth := filepath.Join(dir, "cicada.yaml")
+
+	if err := os.WriteFile(pth, []byte("extends: [\"cicada.yaml\"]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var index cicada.Index
+
+	if err := index.Merge(pth, cicada.CachePolicy{Dir: dir}, nil); err == nil {
+		t.Errorf("Expected extends cycle error")
+	}
+}
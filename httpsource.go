@@ -0,0 +1,134 @@
+package cicada
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// HTTPGet retrieves a remote resource.
+func HTTPGet(u string) ([]byte, error) {
+	res, err := http.Get(u)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err2 := res.Body.Close(); err2 != nil {
+			log.Print(err2)
+		}
+	}()
+
+	statusCode := res.StatusCode
+
+	if statusCode < 200 || statusCode > 299 {
+		return nil, fmt.Errorf("get: %v returned status code: %v", u, statusCode)
+	}
+
+	return io.ReadAll(res.Body)
+}
+
+// HTTPSource reads LTS schedules from a generic HTTP JSON endpoint.
+//
+// The document is a JSON object keyed on component name,
+// with values in endoflife.date product detail record format.
+//
+// For example:
+//
+//	{"widget": [{"cycle": "2.1", "codename": "bolt", "eol": "2030-01-31"}]}
+type HTTPSource struct {
+	// URL denotes the document location.
+	URL string
+
+	// CachePath denotes the local copy of the document.
+	CachePath string
+
+	// Policy controls refreshing of the local copy.
+	Policy CachePolicy
+
+	// records caches the decoded document.
+	records map[string]ProductRecords
+}
+
+// load ensures the document is cached and decoded.
+func (o *HTTPSource) load() error {
+	if o.records != nil {
+		return nil
+	}
+
+	refresh, err := o.Policy.Refresh(o.CachePath)
+
+	if err != nil {
+		return err
+	}
+
+	if refresh {
+		log.Printf("Caching new product data from %v...\n", o.URL)
+
+		body, err2 := HTTPGet(o.URL)
+
+		if err2 != nil {
+			return err2
+		}
+
+		if err2 := os.MkdirAll(filepath.Dir(o.CachePath), os.ModePerm); err2 != nil {
+			return err2
+		}
+
+		if err2 := os.WriteFile(o.CachePath, body, 0644); err2 != nil {
+			return err2
+		}
+	}
+
+	body, err := os.ReadFile(o.CachePath)
+
+	if err != nil {
+		return err
+	}
+
+	records := make(map[string]ProductRecords)
+
+	if err2 := json.Unmarshal(body, &records); err2 != nil {
+		return err2
+	}
+
+	o.records = records
+	return nil
+}
+
+// Products lists the component names in the document.
+func (o *HTTPSource) Products() ([]string, error) {
+	if err := o.load(); err != nil {
+		return nil, err
+	}
+
+	var products []string
+
+	for product := range o.records {
+		products = append(products, product)
+	}
+
+	sort.Strings(products)
+	return products, nil
+}
+
+// Schedules fetches the support timelines of a component.
+func (o *HTTPSource) Schedules(product string) ([]Schedule, error) {
+	if err := o.load(); err != nil {
+		return nil, err
+	}
+
+	records, ok := o.records[product]
+
+	if !ok {
+		return nil, fmt.Errorf("unknown product: %v", product)
+	}
+
+	return ProductRecordsToSchedules(product, records)
+}
@@ -0,0 +1,744 @@
+package cicada
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// MaxImageMetadataSize bounds image manifest and configuration documents.
+const MaxImageMetadataSize = 4 * 1024 * 1024
+
+// ImageSmallFileSize denotes the size under which image file content is always retained,
+// in order to capture small version files.
+const ImageSmallFileSize = 4096
+
+// DockerArchiveManifestBase denotes the docker save manifest.
+const DockerArchiveManifestBase = "manifest.json"
+
+// OCIImageIndexBase denotes the OCI image-layout index.
+const OCIImageIndexBase = "index.json"
+
+// DockerArchiveManifest models docker save manifest entries.
+type DockerArchiveManifest struct {
+	// Config denotes the image configuration path.
+	Config string `json:"Config"`
+
+	// RepoTags denotes the image names.
+	RepoTags []string `json:"RepoTags"`
+
+	// Layers denotes the layer paths, from base to top.
+	Layers []string `json:"Layers"`
+}
+
+// OCIDescriptor models OCI content descriptors.
+type OCIDescriptor struct {
+	// MediaType denotes the content format.
+	MediaType string `json:"mediaType"`
+
+	// Digest denotes the content address.
+	Digest string `json:"digest"`
+
+	// Platform denotes the target platform of image manifests.
+	Platform *OCIPlatform `json:"platform,omitempty"`
+}
+
+// OCIPlatform models OCI platform selectors.
+type OCIPlatform struct {
+	// Architecture denotes a GOARCH value.
+	Architecture string `json:"architecture"`
+
+	// OS denotes a GOOS value.
+	OS string `json:"os"`
+}
+
+// OCIIndex models OCI image indices.
+type OCIIndex struct {
+	// Manifests denotes the image manifests or nested indices.
+	Manifests []OCIDescriptor `json:"manifests"`
+}
+
+// OCIManifest models OCI image manifests.
+type OCIManifest struct {
+	// MediaType denotes the manifest format.
+	MediaType string `json:"mediaType"`
+
+	// Config denotes the image configuration.
+	Config OCIDescriptor `json:"config"`
+
+	// Layers denotes the layers, from base to top.
+	Layers []OCIDescriptor `json:"layers"`
+
+	// Manifests denotes nested manifests, when the document is an index.
+	Manifests []OCIDescriptor `json:"manifests"`
+}
+
+// ImageConfig models the relevant portion of image configurations.
+type ImageConfig struct {
+	// Config denotes the container defaults.
+	Config struct {
+		// Env denotes KEY=value environment entries.
+		Env []string `json:"Env"`
+
+		// Labels denotes image annotations, such as org.opencontainers.image.version.
+		Labels map[string]string `json:"Labels"`
+	} `json:"config"`
+}
+
+// ImageArchive models a flattened container image tarball.
+type ImageArchive struct {
+	// FS denotes the merged layer filesystem.
+	FS *MemFS
+
+	// Env denotes the KEY=value environment of the image configuration.
+	Env []string
+
+	// Labels denotes the labels of the image configuration.
+	Labels map[string]string
+}
+
+// OCIBlobPath locates a blob within an OCI image-layout.
+func OCIBlobPath(digest string) (*string, error) {
+	algorithm, encoded, ok := strings.Cut(digest, ":")
+
+	if !ok || algorithm == "" || encoded == "" || strings.ContainsAny(encoded, "/.") {
+		return nil, fmt.Errorf("invalid digest: %v", digest)
+	}
+
+	pth := path.Join("blobs", algorithm, encoded)
+	return &pth, nil
+}
+
+// archiveEntry locates the content of a regular file within an archive.
+type archiveEntry struct {
+	// offset denotes the content position.
+	offset int64
+
+	// size denotes the content length.
+	size int64
+}
+
+// archiveIndex models a tarball indexed by entry name, for random access.
+type archiveIndex struct {
+	// archive denotes the tarball.
+	archive io.ReadSeeker
+
+	// entries denotes regular files, keyed on clean slash-separated path.
+	entries map[string]archiveEntry
+}
+
+// indexArchive reads the headers of a tarball once,
+// recording the position of each regular file.
+//
+// Entry content is skipped by seeking, rather than read.
+func indexArchive(archive io.ReadSeeker) (*archiveIndex, error) {
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	index := archiveIndex{archive: archive, entries: make(map[string]archiveEntry)}
+	tr := tar.NewReader(archive)
+
+	for {
+		header, err := tr.Next()
+
+		if err == io.EOF {
+			return &index, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		name := cleanName(header.Name)
+
+		if _, ok := index.entries[name]; ok || header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		offset, err := archive.Seek(0, io.SeekCurrent)
+
+		if err != nil {
+			return nil, err
+		}
+
+		index.entries[name] = archiveEntry{offset: offset, size: header.Size}
+	}
+}
+
+// visit passes the content of an archive entry to f.
+//
+// A false result indicates the entry is missing.
+func (o archiveIndex) visit(name string, f func(io.Reader) error) (bool, error) {
+	entry, ok := o.entries[cleanName(name)]
+
+	if !ok {
+		return false, nil
+	}
+
+	if _, err := o.archive.Seek(entry.offset, io.SeekStart); err != nil {
+		return false, err
+	}
+
+	return true, f(io.LimitReader(o.archive, entry.size))
+}
+
+// readArchiveMetadata reads a JSON document from an archive.
+//
+// A false result indicates the entry is missing.
+func readArchiveMetadata(archive *archiveIndex, name string, v interface{}) (bool, error) {
+	return archive.visit(name, func(r io.Reader) error {
+		content, err := io.ReadAll(io.LimitReader(r, MaxImageMetadataSize))
+
+		if err != nil {
+			return err
+		}
+
+		if err2 := json.Unmarshal(content, v); err2 != nil {
+			return fmt.Errorf("%v: %v", name, err2)
+		}
+
+		return nil
+	})
+}
+
+// SelectOCIManifest chooses the image manifest matching the current platform,
+// falling back to the first manifest.
+func SelectOCIManifest(manifests []OCIDescriptor) (*OCIDescriptor, error) {
+	return SelectPlatformManifest(manifests, "")
+}
+
+// SelectPlatformManifest chooses the image manifest matching an os/arch platform,
+// such as linux/arm64, falling back to the first manifest.
+//
+// Blank platform indicates linux on the current architecture.
+func SelectPlatformManifest(manifests []OCIDescriptor, platform string) (*OCIDescriptor, error) {
+	if len(manifests) == 0 {
+		return nil, fmt.Errorf("image index lists no manifests")
+	}
+
+	operatingSystem, architecture := "linux", runtime.GOARCH
+
+	if platform != "" {
+		parts := strings.Split(platform, "/")
+		operatingSystem = parts[0]
+
+		if len(parts) > 1 {
+			architecture = parts[1]
+		}
+	}
+
+	for _, descriptor := range manifests {
+		if descriptor.Platform != nil && descriptor.Platform.OS == operatingSystem && descriptor.Platform.Architecture == architecture {
+			return &descriptor, nil
+		}
+	}
+
+	return &manifests[0], nil
+}
+
+// resolveOCILayers follows an OCI image-layout index to the configuration and layer paths.
+func resolveOCILayers(archive *archiveIndex) (*string, []string, error) {
+	var index OCIIndex
+
+	found, err := readArchiveMetadata(archive, OCIImageIndexBase, &index)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !found {
+		return nil, nil, fmt.Errorf("missing %v or %v: not a docker save or OCI image-layout archive", DockerArchiveManifestBase, OCIImageIndexBase)
+	}
+
+	manifests := index.Manifests
+
+	// Bound nested image indices.
+	for depth := 0; depth < 4; depth++ {
+		descriptorP, err2 := SelectOCIManifest(manifests)
+
+		if err2 != nil {
+			return nil, nil, err2
+		}
+
+		blobPathP, err2 := OCIBlobPath(descriptorP.Digest)
+
+		if err2 != nil {
+			return nil, nil, err2
+		}
+
+		var manifest OCIManifest
+
+		found, err2 := readArchiveMetadata(archive, *blobPathP, &manifest)
+
+		if err2 != nil {
+			return nil, nil, err2
+		}
+
+		if !found {
+			return nil, nil, fmt.Errorf("missing blob: %v", descriptorP.Digest)
+		}
+
+		if len(manifest.Manifests) != 0 {
+			manifests = manifest.Manifests
+			continue
+		}
+
+		configPathP, err2 := OCIBlobPath(manifest.Config.Digest)
+
+		if err2 != nil {
+			return nil, nil, err2
+		}
+
+		var layers []string
+
+		for _, layer := range manifest.Layers {
+			layerPathP, err3 := OCIBlobPath(layer.Digest)
+
+			if err3 != nil {
+				return nil, nil, err3
+			}
+
+			layers = append(layers, *layerPathP)
+		}
+
+		return configPathP, layers, nil
+	}
+
+	return nil, nil, fmt.Errorf("image index nesting too deep")
+}
+
+// openLayer decompresses a layer tarball.
+//
+// Gzip compressed layers are detected automatically.
+// Callers invoke the returned function to release the decompressor.
+func openLayer(r io.Reader) (*tar.Reader, func(), error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(4)
+
+	if err != nil && err != io.EOF {
+		return nil, nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gr, err2 := gzip.NewReader(br)
+
+		if err2 != nil {
+			return nil, nil, err2
+		}
+
+		return tar.NewReader(gr), func() {
+			if err3 := gr.Close(); err3 != nil {
+				log.Print(err3)
+			}
+		}, nil
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return nil, nil, fmt.Errorf("unsupported zstd compressed layer")
+	}
+
+	return tar.NewReader(br), func() {}, nil
+}
+
+// addLayerEntry copies a layer entry onto a filesystem.
+//
+// keep reports whether to retain the content of large regular files.
+func addLayerEntry(fsys *MemFS, tr *tar.Reader, header *tar.Header, name string, keep func(name string) bool) error {
+	switch header.Typeflag {
+	case tar.TypeDir:
+		fsys.AddDir(name, header.FileInfo().Mode(), header.ModTime)
+	case tar.TypeReg:
+		var data []byte
+
+		if header.Size <= ImageSmallFileSize || keep(name) {
+			content, err := io.ReadAll(tr)
+
+			if err != nil {
+				return err
+			}
+
+			data = content
+		}
+
+		fsys.AddFile(name, header.FileInfo().Mode(), data, header.Size, header.ModTime)
+	case tar.TypeSymlink:
+		fsys.AddSymlink(name, header.Linkname, header.ModTime)
+	case tar.TypeLink:
+		return fsys.AddLink(name, header.Linkname)
+	}
+
+	return nil
+}
+
+// ApplyLayer merges a layer tarball onto a filesystem.
+//
+// Gzip compressed layers are detected automatically.
+// OCI whiteout entries delete lower layer content.
+//
+// keep reports whether to retain the content of large regular files.
+func ApplyLayer(fsys *MemFS, r io.Reader, keep func(name string) bool) error {
+	tr, closeLayer, err := openLayer(r)
+
+	if err != nil {
+		return err
+	}
+
+	defer closeLayer()
+	added := make(map[string]bool)
+
+	for {
+		header, err2 := tr.Next()
+
+		if err2 == io.EOF {
+			return nil
+		}
+
+		if err2 != nil {
+			return err2
+		}
+
+		name := cleanName(header.Name)
+		dir, base := path.Dir(name), path.Base(name)
+
+		switch {
+		case base == ".wh..wh..opq":
+			for _, descendant := range fsys.Descendants(dir) {
+				if !added[descendant] {
+					delete(fsys.nodes, descendant)
+				}
+			}
+
+			continue
+		case strings.HasPrefix(base, ".wh."):
+			fsys.Remove(path.Join(dir, strings.TrimPrefix(base, ".wh.")))
+			continue
+		}
+
+		added[name] = true
+
+		if err3 := addLayerEntry(fsys, tr, header, name, keep); err3 != nil {
+			return err3
+		}
+	}
+}
+
+// Whiteouts records the paths deleted by upper layers,
+// in order to apply image layers from the top down.
+type Whiteouts struct {
+	// removed denotes whiteout paths.
+	removed map[string]bool
+
+	// opaque denotes directories hiding all lower layer content.
+	opaque map[string]bool
+}
+
+// NewWhiteouts constructs an empty Whiteouts.
+func NewWhiteouts() *Whiteouts {
+	return &Whiteouts{removed: make(map[string]bool), opaque: make(map[string]bool)}
+}
+
+// Hides reports whether upper layers delete a path.
+func (o Whiteouts) Hides(name string) bool {
+	if o.removed[name] {
+		return true
+	}
+
+	for dir := path.Dir(name); ; dir = path.Dir(dir) {
+		if o.removed[dir] || o.opaque[dir] {
+			return true
+		}
+
+		if dir == "." || dir == "/" {
+			return false
+		}
+	}
+}
+
+// ApplyLowerLayer merges a layer tarball beneath the content of upper layers,
+// which were applied earlier.
+//
+// Content present in upper layers, or deleted by upper layer whiteouts, is skipped.
+// The whiteouts of this layer are recorded for layers further below.
+//
+// keep reports whether to retain the content of large regular files.
+func ApplyLowerLayer(fsys *MemFS, r io.Reader, keep func(name string) bool, whiteouts *Whiteouts) error {
+	tr, closeLayer, err := openLayer(r)
+
+	if err != nil {
+		return err
+	}
+
+	defer closeLayer()
+	layerWhiteouts := NewWhiteouts()
+
+	for {
+		header, err2 := tr.Next()
+
+		if err2 == io.EOF {
+			break
+		}
+
+		if err2 != nil {
+			return err2
+		}
+
+		name := cleanName(header.Name)
+		dir, base := path.Dir(name), path.Base(name)
+
+		switch {
+		case base == ".wh..wh..opq":
+			layerWhiteouts.opaque[dir] = true
+			continue
+		case strings.HasPrefix(base, ".wh."):
+			layerWhiteouts.removed[path.Join(dir, strings.TrimPrefix(base, ".wh."))] = true
+			continue
+		}
+
+		if whiteouts.Hides(name) || fsys.shadows(name) {
+			continue
+		}
+
+		// Hard link targets may be shadowed by upper layers.
+		if header.Typeflag == tar.TypeLink {
+			if _, ok := fsys.nodes[cleanName(header.Linkname)]; !ok {
+				continue
+			}
+		}
+
+		if err3 := addLayerEntry(fsys, tr, header, name, keep); err3 != nil {
+			return err3
+		}
+	}
+
+	for name := range layerWhiteouts.removed {
+		whiteouts.removed[name] = true
+	}
+
+	for name := range layerWhiteouts.opaque {
+		whiteouts.opaque[name] = true
+	}
+
+	return nil
+}
+
+// ReadImage flattens a docker save or OCI image-layout archive.
+//
+// keep reports whether to retain the content of large regular files.
+// Small files are always retained.
+func ReadImage(r io.ReadSeeker, keep func(name string) bool) (*ImageArchive, error) {
+	archive, err := indexArchive(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var manifests []DockerArchiveManifest
+
+	found, err := readArchiveMetadata(archive, DockerArchiveManifestBase, &manifests)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var configPath string
+	var layers []string
+
+	if found {
+		if len(manifests) == 0 {
+			return nil, fmt.Errorf("%v lists no images", DockerArchiveManifestBase)
+		}
+
+		if len(manifests) > 1 {
+			return nil, fmt.Errorf("%v lists multiple images; save one image per archive", DockerArchiveManifestBase)
+		}
+
+		configPath = manifests[0].Config
+		layers = manifests[0].Layers
+	} else {
+		configPathP, ociLayers, err2 := resolveOCILayers(archive)
+
+		if err2 != nil {
+			return nil, err2
+		}
+
+		configPath = *configPathP
+		layers = ociLayers
+	}
+
+	var config ImageConfig
+
+	if configPath != "" {
+		found, err2 := readArchiveMetadata(archive, configPath, &config)
+
+		if err2 != nil {
+			return nil, err2
+		}
+
+		if !found {
+			return nil, fmt.Errorf("missing image configuration: %v", configPath)
+		}
+	}
+
+	image := ImageArchive{FS: NewMemFS(), Env: config.Config.Env, Labels: config.Config.Labels}
+
+	for _, layer := range layers {
+		found, err2 := archive.visit(layer, func(layerReader io.Reader) error {
+			return ApplyLayer(image.FS, layerReader, keep)
+		})
+
+		if err2 != nil {
+			return nil, fmt.Errorf("%v: %v", layer, err2)
+		}
+
+		if !found {
+			return nil, fmt.Errorf("missing layer: %v", layer)
+		}
+	}
+
+	return &image, nil
+}
+
+// ReadImageFile flattens a docker save or OCI image-layout tarball.
+func ReadImageFile(pth string, keep func(name string) bool) (*ImageArchive, error) {
+	f, err := os.Open(pth)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err2 := f.Close(); err2 != nil {
+			log.Print(err2)
+		}
+	}()
+
+	return ReadImage(f, keep)
+}
+
+// ImageKeep reports whether root scans read a file,
+// according to RootPathPatterns.
+func (o Index) ImageKeep() func(name string) bool {
+	patterns := o.RootPathPatterns()
+
+	return func(name string) bool {
+		for _, pattern := range patterns {
+			if matched, err := path.Match(pattern, name); err == nil && matched {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// ScanImage generates LTS warnings for a docker save or OCI image-layout tarball.
+func (o Index) ScanImage(ctx context.Context, pth string) ([]string, error) {
+	image, err := ReadImageFile(pth, o.ImageKeep())
+
+	if err != nil {
+		return nil, err
+	}
+
+	root, cleanup, err := image.Root()
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer cleanup()
+	return o.ScanRoot(ctx, *root)
+}
+
+// HasRpmDatabase reports whether a root filesystem contains an RPM database.
+func HasRpmDatabase(fsys fs.FS) bool {
+	for _, pth := range RpmDatabasePaths {
+		if fi, err := fs.Stat(fsys, pth); err == nil && fi.IsDir() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ExtractRpmDatabases copies any RPM databases of a root filesystem
+// into a host directory.
+func ExtractRpmDatabases(fsys fs.FS, dir string) error {
+	for _, pth := range RpmDatabasePaths {
+		if fi, err := fs.Stat(fsys, pth); err != nil || !fi.IsDir() {
+			continue
+		}
+
+		if err := fs.WalkDir(fsys, pth, func(name string, d fs.DirEntry, err2 error) error {
+			if err2 != nil {
+				return err2
+			}
+
+			target := filepath.Join(dir, filepath.FromSlash(name))
+
+			if d.IsDir() {
+				return os.MkdirAll(target, 0755)
+			}
+
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			data, err3 := fs.ReadFile(fsys, name)
+
+			if err3 != nil {
+				return err3
+			}
+
+			return os.WriteFile(target, data, 0644)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Root prepares a flattened image for root scans.
+//
+// The rpm tool cannot read in-memory filesystems,
+// so any RPM databases are copied to a temporary host directory.
+// cleanup removes the directory.
+func (o ImageArchive) Root() (*Root, func(), error) {
+	root := Root{FS: o.FS, Environ: o.Env}
+
+	if !HasRpmDatabase(o.FS) {
+		return &root, func() {}, nil
+	}
+
+	dir, err := os.MkdirTemp("", "cicada-rpm-")
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cleanup := func() {
+		if err2 := os.RemoveAll(dir); err2 != nil {
+			log.Print(err2)
+		}
+	}
+
+	if err2 := ExtractRpmDatabases(o.FS, dir); err2 != nil {
+		cleanup()
+		return nil, nil, err2
+	}
+
+	root.Dir = dir
+	return &root, cleanup, nil
+}
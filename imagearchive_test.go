@@ -0,0 +1,297 @@
+package cicada_test
+
+import (
+	"github.com/mcandre/cicada"
+
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+// tarEntry models a test archive entry.
+type tarEntry struct {
+	name     string
+	typeflag byte
+	content  string
+	linkname string
+}
+
+func writeTar(t *testing.T, entries []tarEntry) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+
+	for _, entry := range entries {
+		header := tar.Header{
+			Name:     entry.name,
+			Typeflag: entry.typeflag,
+			Linkname: entry.linkname,
+			Mode:     0644,
+			Size:     int64(len(entry.content)),
+		}
+
+		if entry.typeflag == tar.TypeDir {
+			header.Mode = 0755
+		}
+
+		if err := tw.WriteHeader(&header); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tw.Write([]byte(entry.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+
+	if _, err := gw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestReadImageDockerArchive(t *testing.T) {
+	base := writeTar(t, []tarEntry{
+		{name: "etc/", typeflag: tar.TypeDir},
+		{name: "usr/lib/os-release", typeflag: tar.TypeReg, content: "ID=debian\nVERSION_ID=\"11\"\n"},
+		{name: "etc/os-release", typeflag: tar.TypeSymlink, linkname: "../usr/lib/os-release"},
+		{name: "opt/legacy/VERSION", typeflag: tar.TypeReg, content: "1.0\n"},
+		{name: "opt/stale/a", typeflag: tar.TypeReg, content: "a"},
+	})
+
+	top := gzipBytes(t, writeTar(t, []tarEntry{
+		{name: "opt/.wh.legacy", typeflag: tar.TypeReg},
+		{name: "opt/stale/.wh..wh..opq", typeflag: tar.TypeReg},
+		{name: "opt/stale/b", typeflag: tar.TypeReg, content: "b"},
+		{name: "usr/local/go/VERSION", typeflag: tar.TypeReg, content: "go1.19.13\ntime 2023-09-06\n"},
+	}))
+
+	manifest, err := json.Marshal([]cicada.DockerArchiveManifest{
+		{
+			Config: "config.json",
+			Layers: []string{"base/layer.tar", "top/layer.tar"},
+		},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive := writeTar(t, []tarEntry{
+		{name: "top/layer.tar", typeflag: tar.TypeReg, content: string(top)},
+		{name: "config.json", typeflag: tar.TypeReg, content: `{"config":{"Env":["PATH=/usr/bin","NODE_VERSION=16.20.2"]}}`},
+		{name: "base/layer.tar", typeflag: tar.TypeReg, content: string(base)},
+		{name: "manifest.json", typeflag: tar.TypeReg, content: string(manifest)},
+	})
+
+	image, err := cicada.ReadImage(bytes.NewReader(archive), func(string) bool { return false })
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err2 := fstest.TestFS(image.FS, "etc/os-release", "opt/stale/b", "usr/local/go/VERSION"); err2 != nil {
+		t.Error(err2)
+	}
+
+	osRelease, err := cicada.ReadOsReleaseFS(image.FS)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if osRelease == nil || osRelease.Product() != "debian" || osRelease.VersionID != "11" {
+		t.Errorf("Expected debian 11 through os-release symlink, got: %v", osRelease)
+	}
+
+	if _, err2 := fs.Stat(image.FS, "opt/legacy/VERSION"); err2 == nil {
+		t.Errorf("Expected whiteout to remove opt/legacy")
+	}
+
+	if _, err2 := fs.Stat(image.FS, "opt/stale/a"); err2 == nil {
+		t.Errorf("Expected opaque whiteout to remove opt/stale/a")
+	}
+
+	if len(image.Env) != 2 || image.Env[1] != "NODE_VERSION=16.20.2" {
+		t.Errorf("Expected image configuration environment, got: %v", image.Env)
+	}
+}
+
+func TestImageArchiveRootRpm(t *testing.T) {
+	layer := writeTar(t, []tarEntry{
+		{name: "usr/lib/sysimage/rpm/", typeflag: tar.TypeDir},
+		{name: "usr/lib/sysimage/rpm/rpmdb.sqlite", typeflag: tar.TypeReg, content: "SQLite format 3"},
+		{name: "var/lib/rpm", typeflag: tar.TypeSymlink, linkname: "../../usr/lib/sysimage/rpm"},
+	})
+
+	fsys := cicada.NewMemFS()
+
+	if err := cicada.ApplyLayer(fsys, bytes.NewReader(layer), func(string) bool { return false }); err != nil {
+		t.Fatal(err)
+	}
+
+	root, cleanup, err := cicada.ImageArchive{FS: fsys}.Root()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if root.Dir == "" {
+		t.Fatal("Expected a host directory for the rpm database")
+	}
+
+	data, err := os.ReadFile(filepath.Join(root.Dir, "usr", "lib", "sysimage", "rpm", "rpmdb.sqlite"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "SQLite format 3" {
+		t.Errorf("Expected rpm database content, got: %v", string(data))
+	}
+
+	cleanup()
+
+	if _, err2 := os.Stat(root.Dir); err2 == nil {
+		t.Errorf("Expected cleanup to remove %v", root.Dir)
+	}
+
+	root, cleanup, err = cicada.ImageArchive{FS: cicada.NewMemFS()}.Root()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cleanup()
+
+	if root.Dir != "" {
+		t.Errorf("Expected no host directory without an rpm database, got: %v", root.Dir)
+	}
+}
+
+func TestApplyLowerLayer(t *testing.T) {
+	base := writeTar(t, []tarEntry{
+		{name: "etc/", typeflag: tar.TypeDir},
+		{name: "etc/os-release", typeflag: tar.TypeReg, content: "ID=debian\nVERSION_ID=\"11\"\n"},
+		{name: "opt/legacy/VERSION", typeflag: tar.TypeReg, content: "1.0\n"},
+		{name: "opt/stale/a", typeflag: tar.TypeReg, content: "a"},
+		{name: "usr/local/go/VERSION", typeflag: tar.TypeReg, content: "go1.18\n"},
+	})
+
+	top := gzipBytes(t, writeTar(t, []tarEntry{
+		{name: "opt/.wh.legacy", typeflag: tar.TypeReg},
+		{name: "opt/stale/.wh..wh..opq", typeflag: tar.TypeReg},
+		{name: "opt/stale/b", typeflag: tar.TypeReg, content: "b"},
+		{name: "usr/local/go/VERSION", typeflag: tar.TypeReg, content: "go1.19.13\n"},
+	}))
+
+	fsys := cicada.NewMemFS()
+	whiteouts := cicada.NewWhiteouts()
+	keep := func(string) bool { return false }
+
+	for _, layer := range [][]byte{top, base} {
+		if err := cicada.ApplyLowerLayer(fsys, bytes.NewReader(layer), keep, whiteouts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := fstest.TestFS(fsys, "etc/os-release", "opt/stale/b", "usr/local/go/VERSION"); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := fs.Stat(fsys, "opt/legacy/VERSION"); err == nil {
+		t.Errorf("Expected upper whiteout to hide opt/legacy")
+	}
+
+	if _, err := fs.Stat(fsys, "opt/stale/a"); err == nil {
+		t.Errorf("Expected upper opaque whiteout to hide opt/stale/a")
+	}
+
+	goVersion, err := fs.ReadFile(fsys, "usr/local/go/VERSION")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(goVersion) != "go1.19.13\n" {
+		t.Errorf("Expected upper layer content to take precedence, got: %q", goVersion)
+	}
+}
+
+// countingReadSeeker tallies the bytes read from an archive.
+type countingReadSeeker struct {
+	*bytes.Reader
+	n int
+}
+
+func (o *countingReadSeeker) Read(p []byte) (int, error) {
+	n, err := o.Reader.Read(p)
+	o.n += n
+	return n, err
+}
+
+func TestReadImageReadsHeadersOnce(t *testing.T) {
+	var layerNames []string
+	var layerEntries []tarEntry
+
+	for i := 0; i < 3; i++ {
+		name := fmt.Sprintf("layer%d/layer.tar", i)
+		layerNames = append(layerNames, name)
+		layerEntries = append(layerEntries, tarEntry{name: name, typeflag: tar.TypeReg, content: string(writeTar(t, []tarEntry{
+			{name: fmt.Sprintf("opt/%d", i), typeflag: tar.TypeReg, content: "x"},
+		}))})
+	}
+
+	manifest, err := json.Marshal([]cicada.DockerArchiveManifest{{Config: "config.json", Layers: layerNames}})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := []tarEntry{
+		{name: "manifest.json", typeflag: tar.TypeReg, content: string(manifest)},
+		{name: "config.json", typeflag: tar.TypeReg, content: `{"config":{}}`},
+	}
+
+	for i := 0; i < 500; i++ {
+		entries = append(entries, tarEntry{name: fmt.Sprintf("padding/%d", i), typeflag: tar.TypeReg, content: "x"})
+	}
+
+	archive := writeTar(t, append(entries, layerEntries...))
+	reader := &countingReadSeeker{Reader: bytes.NewReader(archive)}
+
+	image, err := cicada.ReadImage(reader, func(string) bool { return false })
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err2 := fstest.TestFS(image.FS, "opt/0", "opt/1", "opt/2"); err2 != nil {
+		t.Error(err2)
+	}
+
+	if reader.n > 2*len(archive) {
+		t.Errorf("Expected archive headers read once, read %d bytes of a %d byte archive", reader.n, len(archive))
+	}
+}
@@ -0,0 +1,175 @@
+package cicada
+
+import (
+	"github.com/Masterminds/semver"
+
+	"regexp"
+	"strings"
+)
+
+// ImageProducts maps Docker Hub official image names to endoflife.date products,
+// where the names differ.
+var ImageProducts = map[string]string{
+	"amazonlinux": "amazon-linux",
+	"golang":      "go",
+	"mongo":       "mongodb",
+	"node":        "nodejs",
+	"postgres":    "postgresql",
+	"rockylinux":  "rocky-linux",
+}
+
+// FloatingTags lists image tags that track a moving release series,
+// rather than pinning one.
+var FloatingTags = []string{"latest", "stable", "current", "lts"}
+
+// FloatingTagAliases maps floating tags to their meaning for particular products,
+// where it differs from the newest release series.
+//
+// For example, ubuntu:latest denotes the newest LTS release.
+var FloatingTagAliases = map[string]map[string]string{
+	"ubuntu": {"latest": "lts"},
+}
+
+// IsFloatingTag reports whether an image tag tracks a moving release series.
+func IsFloatingTag(tag string) bool {
+	for _, floatingTag := range FloatingTags {
+		if tag == floatingTag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ResolveFloatingTag selects the release series that a floating tag currently denotes:
+// the newest LTS series for lts, otherwise the newest series.
+//
+// nil indicates no matching series.
+func ResolveFloatingTag(product string, tag string, schedules []Schedule) *Schedule {
+	if alias, ok := FloatingTagAliases[product][tag]; ok {
+		tag = alias
+	}
+
+	return LatestSchedule(schedules, tag == "lts")
+}
+
+// ImageOsTag models a tag suffix that identifies the underlying operating system of an image.
+type ImageOsTag struct {
+	// Pattern matches tag suffixes,
+	// with a Version or Codename group.
+	Pattern *regexp.Regexp
+
+	// Product denotes an endoflife.date operating system product.
+	Product string
+}
+
+// ImageOsTags lists the base OS suffixes of official image tags,
+// such as python:3.8-slim-buster and golang:1.19-alpine3.15.
+var ImageOsTags = []ImageOsTag{
+	{Pattern: regexp.MustCompile(`^(?P<Codename>jessie|stretch|buster|bullseye|bookworm|trixie)$`), Product: "debian"},
+	{Pattern: regexp.MustCompile(`^(?P<Codename>trusty|xenial|bionic|focal|jammy|noble)$`), Product: "ubuntu"},
+	{Pattern: regexp.MustCompile(`^alpine(?P<Version>[0-9]+\.[0-9]+)$`), Product: "alpine"},
+	{Pattern: regexp.MustCompile(`^ubi(?P<Version>[0-9]+)(-minimal)?$`), Product: "rhel"},
+	{Pattern: regexp.MustCompile(`^(windowsservercore|nanoserver)-ltsc(?P<Version>[0-9]{4})$`), Product: "windows-server"},
+}
+
+// ImageComponent models a software component identified by an image reference.
+type ImageComponent struct {
+	// Product denotes an endoflife.date product.
+	Product string
+
+	// Version denotes any parsed version.
+	Version *semver.Version
+
+	// Codename denotes any release name, in lieu of a version.
+	Codename string
+
+	// Floating denotes any floating tag, such as latest, in lieu of a version.
+	Floating string
+}
+
+// Name formats the component version for logging.
+func (o ImageComponent) Name() string {
+	switch {
+	case o.Version != nil:
+		return o.Product + " " + o.Version.Original()
+	case o.Floating != "":
+		return o.Product + " " + o.Floating
+	default:
+		return o.Product + " " + o.Codename
+	}
+}
+
+// ImageOsComponent decomposes the base OS suffix of an image tag, if any.
+func ImageOsComponent(tag string) (ImageComponent, bool) {
+	parts := strings.Split(tag, "-")
+
+	for i := range parts {
+		for j := i + 1; j <= len(parts); j++ {
+			candidate := strings.Join(parts[i:j], "-")
+
+			for _, osTag := range ImageOsTags {
+				match := osTag.Pattern.FindStringSubmatch(candidate)
+
+				if match == nil {
+					continue
+				}
+
+				component := ImageComponent{Product: osTag.Product}
+
+				if k := osTag.Pattern.SubexpIndex("Codename"); k >= 0 {
+					component.Codename = match[k]
+					return component, true
+				}
+
+				versionP, err := semver.NewVersion(match[osTag.Pattern.SubexpIndex("Version")])
+
+				if err != nil {
+					return component, false
+				}
+
+				component.Version = versionP
+				return component, true
+			}
+		}
+	}
+
+	return ImageComponent{}, false
+}
+
+// ImageProduct maps an image name to an endoflife.date product,
+// according to ImageProducts, or else the name itself.
+func ImageProduct(name string) string {
+	name = strings.TrimSuffix(name, "-slim")
+
+	if product, ok := ImageProducts[name]; ok {
+		return product
+	}
+
+	return name
+}
+
+// Components decomposes an image reference into the software components it identifies:
+// the image's own runtime or OS, and any base OS named by a tag suffix.
+//
+// For example, python:3.8-slim-buster yields python 3.8 and debian buster.
+func (o Image) Components() []ImageComponent {
+	product := ImageProduct(o.Name)
+	var components []ImageComponent
+	osComponent, hasOs := ImageOsComponent(o.Tag)
+	first, _, _ := strings.Cut(o.Tag, "-")
+
+	if versionP, err := semver.NewVersion(first); err == nil {
+		components = append(components, ImageComponent{Product: product, Version: versionP})
+	} else if IsFloatingTag(first) {
+		components = append(components, ImageComponent{Product: product, Floating: first})
+	} else if !hasOs {
+		components = append(components, ImageComponent{Product: product, Codename: first})
+	}
+
+	if hasOs && (len(components) == 0 || components[0].Product != osComponent.Product) {
+		components = append(components, osComponent)
+	}
+
+	return components
+}
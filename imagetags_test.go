@@ -0,0 +1,129 @@
+package cicada_test
+
+import (
+	"github.com/mcandre/cicada"
+	"gopkg.in/yaml.v3"
+
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestImageComponents(t *testing.T) {
+	for reference, expected := range map[string]string{
+		"python:3.8-slim-buster":                                          "python 3.8, debian buster",
+		"golang:1.19-alpine3.15":                                          "go 1.19, alpine 3.15",
+		"node:18-bookworm-slim":                                           "nodejs 18, debian bookworm",
+		"eclipse-temurin:17-jre-jammy":                                    "eclipse-temurin 17, ubuntu jammy",
+		"example.com/openjdk:11-jre-ubi8-minimal":                         "openjdk 11, rhel 8",
+		"mcr.microsoft.com/dotnet/runtime:6.0-windowsservercore-ltsc2019": "runtime 6.0, windows-server 2019",
+		"debian:bullseye-slim":                                            "debian bullseye",
+		"alpine:3.15":                                                     "alpine 3.15",
+		"ubuntu:hirsute":                                                  "ubuntu hirsute",
+		"node:lts-alpine3.18":                                             "nodejs lts, alpine 3.18",
+		"python:slim-bookworm":                                            "debian bookworm",
+	} {
+		var names []string
+
+		for _, component := range cicada.ParseImageReference(reference).Components() {
+			names = append(names, component.Name())
+		}
+
+		if actual := strings.Join(names, ", "); actual != expected {
+			t.Errorf("Expected %v to decompose to %q, got: %q", reference, expected, actual)
+		}
+	}
+}
+
+func TestResolveFloatingTag(t *testing.T) {
+	var schedules []cicada.Schedule
+
+	if err := yaml.Unmarshal([]byte(`
+- version: "21"
+- version: "20"
+  lts: true
+- version: "18"
+  lts: true
+`), &schedules); err != nil {
+		t.Fatal(err)
+	}
+
+	for tag, expected := range map[string]string{
+		"latest":  "21",
+		"current": "21",
+		"lts":     "20",
+	} {
+		schedule := cicada.ResolveFloatingTag("nodejs", tag, schedules)
+
+		if schedule == nil || schedule.Version.Original() != expected {
+			t.Errorf("Expected %v to resolve to %v, got: %v", tag, expected, schedule)
+		}
+	}
+
+	if schedule := cicada.ResolveFloatingTag("ubuntu", "latest", schedules); schedule == nil || schedule.Version.Original() != "20" {
+		t.Errorf("Expected ubuntu:latest to resolve to the newest LTS, got: %v", schedule)
+	}
+}
+
+func TestResolveFloatingTagProductRecords(t *testing.T) {
+	python, err := cicada.ProductRecordsToSchedules("python", cicada.ProductRecords{
+		{"cycle": "3.13", "eol": "2029-10-31"},
+		{"cycle": "3.12", "eol": "2028-10-31"},
+		{"cycle": "3.9", "eol": "2025-10-31"},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(python) != 3 {
+		t.Fatalf("Expected distinct 3.13, 3.12, and 3.9 series, got: %v", python)
+	}
+
+	if scheduleP := cicada.ResolveFloatingTag("python", "latest", python); scheduleP == nil || scheduleP.Version.Original() != "3.13" {
+		t.Errorf("Expected python latest to resolve to 3.13, got: %v", scheduleP)
+	}
+
+	nodejs, err := cicada.ProductRecordsToSchedules("nodejs", cicada.ProductRecords{
+		{"cycle": "23", "lts": false, "eol": "2025-06-01"},
+		{"cycle": "22", "lts": "2024-10-29", "eol": "2027-04-30"},
+		{"cycle": "20", "lts": "2023-10-24", "eol": "2026-04-30"},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if scheduleP := cicada.ResolveFloatingTag("nodejs", "lts", nodejs); scheduleP == nil || scheduleP.Version.Original() != "22" {
+		t.Errorf("Expected node lts to resolve to 22, got: %v", scheduleP)
+	}
+}
+
+func TestScanDockerfilesImageComponents(t *testing.T) {
+	index := loadProject(t, map[string]string{
+		"lc/nodejs.yaml":     "- version: \"18\"\n  expiration: \"2025-04-30\"\n",
+		"lc/postgresql.yaml": "- version: \"11\"\n  expiration: \"2023-11-09\"\n",
+		"lc/alpine.yaml":     "- version: \"3.18\"\n  expiration: \"2025-05-09\"\n",
+		"lc/debian.yaml":     "- version: \"11\"\n  codename: Bullseye\n  expiration: \"2024-08-14\"\n",
+		"Dockerfile":         "FROM node:18.19.0-alpine3.18 AS build\nFROM postgres:11.22-bullseye\n",
+	})
+
+	warnings, err := index.ScanDockerfiles(context.Background(), time.Now())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"end of life for nodejs 18.19.0 on 2025-04-30 at Dockerfile:1",
+		"end of life for alpine 3.18.0 on 2025-05-09 at Dockerfile:1",
+		"end of life for postgresql 11.22.0 on 2023-11-09 at Dockerfile:2",
+		"end of life for debian bullseye on 2024-08-14 at Dockerfile:2",
+	}
+
+	if !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("Expected %v, got: %v", expected, warnings)
+	}
+}
@@ -1,21 +1,15 @@
 package cicada
 
 import (
-	"github.com/Masterminds/semver"
-	"gopkg.in/yaml.v3"
-
-	"bufio"
 	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"os"
-	"os/exec"
 	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 )
@@ -30,13 +24,16 @@ const EndOfLifeBaseURL = "https://endoflife.date/api"
 const ProductsListResourceBase = "all.json"
 
 // IndexCacheRoot denotes the cicada metadata directory base path,
-// relative to the home directory.
+// relative to the project directory.
 const IndexCacheRoot = ".cicada"
 
 // IndexCacheBase denotes the base path of the cached LTS index,
-// relative to the current working directory.
+// relative to the project directory.
 //
 // For example, a software project top level directory.
+//
+// When no explicit configuration is supplied,
+// cicada searches the current working directory and its parents.
 const IndexCacheBase = "cicada.yaml"
 
 // IndexProductsListBase denotes the base path of the products list file,
@@ -49,11 +46,20 @@ const IndexProductsDirBase = "products"
 
 // Index models a catalog of LTS schedules.
 type Index struct {
+	// Extends denotes base configurations, as local paths or URLs.
+	//
+	// Relative paths resolve against the extending configuration.
+	// Remote configurations are cached like product data.
+	//
+	// Keys in the extending configuration take precedence over inherited keys.
+	// version_queries merge per component.
+	Extends []string `json:"extends,omitempty" toml:"extends,omitempty" yaml:"extends,omitempty"`
+
 	// Debug enables additional logging (default: false).
-	Debug bool `json:"debug,omitempty" yaml:"debug,omitempty"`
+	Debug bool `json:"debug,omitempty" toml:"debug,omitempty" yaml:"debug,omitempty"`
 
 	// Quiet skips system executables (default: false).
-	Quiet bool `json:"quiet,omitempty" yaml:"quiet,omitempty"`
+	Quiet bool `json:"quiet,omitempty" toml:"quiet,omitempty" yaml:"quiet,omitempty"`
 
 	// LeadMonths provides a margin of time to migrate
 	// before a support timeline formally ends.
@@ -64,15 +70,102 @@ type Index struct {
 	// Negative values are treated as a reset to default value.
 	//
 	// (default: 1)
-	LeadMonths int `json:"lead_months,omitempty" yaml:"lead_months,omitempty"`
+	LeadMonths int `json:"lead_months,omitempty" toml:"lead_months,omitempty" yaml:"lead_months,omitempty"`
+
+	// CacheDir denotes the cicada metadata directory,
+	// relative to the project directory.
+	//
+	// (default: .cicada)
+	CacheDir string `json:"cache_dir,omitempty" toml:"cache_dir,omitempty" yaml:"cache_dir,omitempty"`
+
+	// Offline prevents network access,
+	// relying on previously cached lifecycle data and configurations (default: false).
+	Offline bool `json:"offline,omitempty" toml:"offline,omitempty" yaml:"offline,omitempty"`
+
+	// Format denotes the report format: "text" or "json".
+	//
+	// (default: text)
+	Format string `json:"format,omitempty" toml:"format,omitempty" yaml:"format,omitempty"`
+
+	// FailThreshold denotes the number of warnings
+	// that triggers a non-zero exit status.
+	//
+	// Zero disables failure.
+	//
+	// (default: 1)
+	FailThreshold int `json:"fail_threshold,omitempty" toml:"fail_threshold,omitempty" yaml:"fail_threshold,omitempty"`
+
+	// QueryTimeout bounds each version query command,
+	// unless the query specifies its own timeout.
+	//
+	// Zero disables the timeout.
+	//
+	// (default: 10s)
+	QueryTimeout Duration `json:"query_timeout,omitempty" toml:"query_timeout,omitempty" yaml:"query_timeout,omitempty"`
+
+	// ScanTimeout bounds each scan as a whole,
+	// including version queries and registry reads.
+	//
+	// Zero disables the timeout.
+	//
+	// (default: 10m)
+	ScanTimeout Duration `json:"scan_timeout,omitempty" toml:"scan_timeout,omitempty" yaml:"scan_timeout,omitempty"`
+
+	// Jobs denotes the number of application version queries run concurrently.
+	//
+	// (default: 4)
+	Jobs int `json:"jobs,omitempty" toml:"jobs,omitempty" yaml:"jobs,omitempty"`
+
+	// AllInstalls checks every matching executable
+	// across PATH and version manager install roots,
+	// rather than only the first executable on PATH (default: false).
+	AllInstalls bool `json:"all_installs,omitempty" toml:"all_installs,omitempty" yaml:"all_installs,omitempty"`
+
+	// Packages checks the package databases of the live host,
+	// on Linux (default: true).
+	Packages bool `json:"packages,omitempty" toml:"packages,omitempty" yaml:"packages,omitempty"`
+
+	// UnpinnedTags reports Dockerfile base images that use floating tags,
+	// such as latest or lts, whose effective version drifts over time (default: false).
+	UnpinnedTags bool `json:"unpinned_tags,omitempty" toml:"unpinned_tags,omitempty" yaml:"unpinned_tags,omitempty"`
+
+	// ResolveImages reads Dockerfile base images from their registries,
+	// over the OCI distribution API,
+	// to identify the true OS and runtime versions (default: false).
+	ResolveImages bool `json:"resolve_images,omitempty" toml:"resolve_images,omitempty" yaml:"resolve_images,omitempty"`
+
+	// BuildArgs overrides Dockerfile ARG defaults,
+	// like docker build --build-arg.
+	BuildArgs map[string]string `json:"-" toml:"-" yaml:"-"`
 
 	// VersionQueries denotes command line queries for retrieving component versions, in exec-like format,
 	// keyed on executable base path.
-	VersionQueries map[string]VersionQuery `json:"version_queries" yaml:"version_queries"`
+	VersionQueries map[string]VersionQuery `json:"version_queries" toml:"version_queries" yaml:"version_queries"`
+
+	// Sources denotes lifecycle data providers, in descending priority.
+	//
+	// Earlier sources shadow later sources on a per-component basis.
+	//
+	// (default: endoflife.date)
+	Sources []SourceConfig `json:"sources,omitempty" toml:"sources,omitempty" yaml:"sources,omitempty"`
 
 	// components denotes version schedules,
 	// keyed on component name.
-	components map[string][]Schedule `json:"-" yaml:"-"`
+	components map[string][]Schedule `json:"-" toml:"-" yaml:"-"`
+
+	// configDir denotes the project configuration directory.
+	configDir string
+
+	// policy controls caching of remote data.
+	policy CachePolicy
+
+	// origins denotes where each setting value came from,
+	// keyed on setting key.
+	origins map[string]string
+
+	// queryLocations denotes the configuration declaring each version query,
+	// excluding built-in defaults.
+	queryLocations map[string]string
 }
 
 // IndexCacheDirPath yields the location of cicada metadata directory.
@@ -87,220 +180,251 @@ func IndexCacheConfigPath(cwd string) (*string, error) {
 	return &pth, nil
 }
 
-// CacheLifetimeData ensures a local copy of endoflife.date records.
-func CacheLifetimeData(indexProductsListFilePath string, indexProductsDirPath string) error {
-	log.Println("Caching new product data...")
-
-	fProductList, err := os.Create(indexProductsListFilePath)
-
-	if err != nil {
-		return err
+// ValidateVersionQueries ensures version query data integrity.
+func (o Index) ValidateVersionQueries() error {
+	for component, query := range o.VersionQueries {
+		if err := query.Validate(); err != nil {
+			return fmt.Errorf("%v: %v", component, err)
+		}
 	}
 
-	u := fmt.Sprintf("%v/%v", EndOfLifeBaseURL, ProductsListResourceBase)
-	res, err := http.Get(u)
+	return nil
+}
 
-	if err != nil {
-		return err
+// ValidateSources ensures lifecycle source data integrity.
+func (o Index) ValidateSources() error {
+	for _, source := range o.Sources {
+		if err := source.Validate(); err != nil {
+			return err
+		}
 	}
 
-	statusCode := res.StatusCode
+	return nil
+}
 
-	if statusCode < 200 || statusCode > 299 {
-		return fmt.Errorf("get: %v returned status code: %v", u, statusCode)
+// Validate ensures data integrity.
+func (o Index) Validate() error {
+	if err := o.ValidateVersionQueries(); err != nil {
+		return err
 	}
 
-	defer func() {
-		if err2 := res.Body.Close(); err2 != nil {
-			log.Print(err2)
-		}
-	}()
-
-	body, err := io.ReadAll(res.Body)
-
-	if err != nil {
+	if err := o.ValidateSettings(); err != nil {
 		return err
 	}
 
-	defer func() {
-		if err2 := fProductList.Close(); err2 != nil {
-			log.Print(err2)
-		}
-	}()
+	return o.ValidateSources()
+}
 
-	if _, err2 := fProductList.Write(body); err2 != nil {
-		return err2
-	}
+// LifecycleSource combines the configured lifecycle sources.
+//
+// configDir anchors relative source paths.
+//
+// policy controls caching of remote data.
+func (o Index) LifecycleSource(configDir string, policy CachePolicy) (LifecycleSource, error) {
+	sourceConfigs := o.Sources
 
-	var products []string
-	if err2 := json.Unmarshal(body, &products); err2 != nil {
-		return err2
+	if len(sourceConfigs) == 0 {
+		sourceConfigs = []SourceConfig{{Kind: SourceKindEndOfLife}}
 	}
 
-	if err2 := os.MkdirAll(indexProductsDirPath, os.ModePerm); err2 != nil {
-		return err2
-	}
+	var sources []LifecycleSource
 
-	for _, product := range products {
-		productBase := fmt.Sprintf("%v.json", product)
-		productFilePath := path.Join(indexProductsDirPath, productBase)
-		fProductDetail, err2 := os.Create(productFilePath)
+	for _, sourceConfig := range sourceConfigs {
+		source, err := sourceConfig.NewLifecycleSource(configDir, policy)
 
-		if err2 != nil {
-			return err2
+		if err != nil {
+			return nil, err
 		}
 
-		u2 := fmt.Sprintf("%v/%v", EndOfLifeBaseURL, productBase)
-		res, err2 := http.Get(u2)
+		sources = append(sources, source)
+	}
 
-		if err2 != nil {
-			return err2
-		}
+	return NewCompositeSource(sources...), nil
+}
 
-		statusCode := res.StatusCode
+// LoadOptions models index loading parameters.
+type LoadOptions struct {
+	// ConfigPath denotes an explicit configuration location.
+	//
+	// Blank indicates discovery, walking up from the current working directory.
+	ConfigPath string
 
-		if statusCode < 200 || statusCode > 299 {
-			return fmt.Errorf("get: %v returned status code: %v", u2, statusCode)
-		}
+	// Update forces cached remote data to refresh.
+	Update bool
 
-		defer func() {
-			if err3 := res.Body.Close(); err3 != nil {
-				log.Print(err3)
-			}
-		}()
+	// Overrides denotes setting values that take precedence over configuration files,
+	// in ascending priority.
+	//
+	// For example, environment variables followed by command line flags.
+	Overrides []Override
+}
+
+// BootstrapCachePolicy resolves the cache settings needed
+// before extended configurations can be merged.
+//
+// Overrides take precedence over the project configuration.
+func BootstrapCachePolicy(configPath *string, projectDir string, options LoadOptions) (*CachePolicy, error) {
+	var root Index
 
-		body, err2 := io.ReadAll(res.Body)
+	if configPath != nil {
+		contentYAML, err := os.ReadFile(*configPath)
 
-		if err2 != nil {
-			return err2
+		if err != nil {
+			return nil, err
 		}
 
-		if _, err3 := fProductDetail.Write(body); err3 != nil {
-			return err3
+		if err2 := DecodeConfig(*configPath, contentYAML, &root); err2 != nil {
+			return nil, fmt.Errorf("%v: %v", *configPath, err2)
 		}
 	}
 
-	return nil
-}
+	for _, override := range options.Overrides {
+		if override.Key != "cache_dir" && override.Key != "offline" {
+			continue
+		}
 
-// ValidateVersionQueries ensures version query data integrity.
-func (o Index) ValidateVersionQueries() error {
-	for component, query := range o.VersionQueries {
-		if len(query.Command) == 0 {
-			return fmt.Errorf("%v has an empty version query", component)
+		if err := root.Set(override.Key, override.Value, override.Origin); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil
-}
+	policy := CachePolicy{
+		Dir:     ResolveCacheDir(projectDir, root.CacheDir),
+		Update:  options.Update,
+		Offline: root.Offline,
+	}
 
-// Validate ensures data integrity.
-func (o Index) Validate() error {
-	return o.ValidateVersionQueries()
+	return &policy, nil
 }
 
-// Load generates a partial LTS index.
-func Load(update bool) (*Index, error) {
-	cwd, err := os.Getwd()
+// LoadConfig generates an LTS index configuration,
+// layering the built-in defaults,
+// any extended configurations,
+// the project configuration,
+// and any overrides,
+// without loading support schedules.
+func LoadConfig(options LoadOptions) (*Index, error) {
+	configPathP, projectDirP, err := LocateConfig(options.ConfigPath)
 
 	if err != nil {
 		return nil, err
 	}
 
-	indexDirPathP, err := IndexCacheDirPath(cwd)
+	projectDir := *projectDirP
+	policyP, err := BootstrapCachePolicy(configPathP, projectDir, options)
 
 	if err != nil {
 		return nil, err
 	}
 
-	indexDirPath := *indexDirPathP
-
-	if err2 := os.MkdirAll(indexDirPath, os.ModePerm); err2 != nil {
-		return nil, err2
-	}
-
-	indexCacheConfigPathP, err := IndexCacheConfigPath(cwd)
+	index, err := NewDefaultIndex()
 
 	if err != nil {
 		return nil, err
 	}
 
-	indexCacheConfigPath := *indexCacheConfigPathP
+	index.configDir = projectDir
 
-	if _, err2 := os.Stat(indexCacheConfigPath); os.IsNotExist(err2) {
-		return nil, fmt.Errorf("missing configuration: %v", IndexCacheBase)
+	if configPathP != nil {
+		if err2 := index.Merge(*configPathP, *policyP, nil); err2 != nil {
+			return nil, err2
+		}
 	}
 
-	indexProductsListFilePath := path.Join(indexDirPath, IndexProductsListBase)
-	indexProductsDirPath := path.Join(indexDirPath, IndexProductsDirBase)
-
-	_, err = os.Stat(indexProductsListFilePath)
-
-	if update || os.IsNotExist(err) {
-		if err2 := CacheLifetimeData(indexProductsListFilePath, indexProductsDirPath); err2 != nil {
+	for _, override := range options.Overrides {
+		if err2 := index.Set(override.Key, override.Value, override.Origin); err2 != nil {
 			return nil, err2
 		}
 	}
 
-	index := new(Index)
-	index.components = make(map[string][]Schedule)
-
-	contentYAML, err := os.ReadFile(indexCacheConfigPath)
+	if err2 := index.Validate(); err2 != nil {
+		return nil, err2
+	}
 
-	if err != nil {
-		return nil, err
+	if index.LeadMonths < 0 {
+		index.LeadMonths = DefaultLeadMonths
 	}
 
-	if err2 := yaml.Unmarshal(contentYAML, index); err2 != nil {
-		return nil, err2
+	index.policy = CachePolicy{
+		Dir:     ResolveCacheDir(projectDir, index.CacheDir),
+		Update:  options.Update,
+		Offline: index.Offline,
 	}
 
-	if err2 := index.Validate(); err2 != nil {
+	if err2 := os.MkdirAll(index.policy.Dir, os.ModePerm); err2 != nil {
 		return nil, err2
 	}
 
-	if index.LeadMonths < 0 {
-		index.LeadMonths = DefaultLeadMonths
+	if index.Debug {
+		index.LogSettings()
 	}
 
-	productListBuf, err := os.ReadFile(indexProductsListFilePath)
+	return index, nil
+}
+
+// LoadSchedules populates support schedules from the configured lifecycle sources.
+func (o *Index) LoadSchedules() error {
+	source, err := o.LifecycleSource(o.configDir, o.policy)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	var products []string
-	if err2 := json.Unmarshal(productListBuf, &products); err2 != nil {
-		return nil, err2
+	products, err := source.Products()
+
+	if err != nil {
+		return err
 	}
 
+	o.components = make(map[string][]Schedule)
+
 	for _, product := range products {
-		productDetailPath := fmt.Sprintf("%v.json", path.Join(indexProductsDirPath, product))
-		productDetailBuf, err2 := os.ReadFile(productDetailPath)
+		schedules, err := source.Schedules(product)
 
-		if err2 != nil {
-			return nil, err2
+		if err != nil {
+			return err
 		}
 
-		var records ProductRecords
-		if err2 := json.Unmarshal(productDetailBuf, &records); err2 != nil {
-			return nil, err2
-		}
+		o.components[product] = schedules
+	}
 
-		schedules, err := ProductRecordsToSchedules(product, records)
+	return nil
+}
 
-		if err != nil {
-			return nil, err
-		}
+// Load generates a partial LTS index.
+//
+// Any configuration lint is logged.
+func Load(options LoadOptions) (*Index, error) {
+	index, err := LoadConfig(options)
 
-		index.components[product] = schedules
+	if err != nil {
+		return nil, err
+	}
+
+	if err2 := index.LoadSchedules(); err2 != nil {
+		return nil, err2
+	}
+
+	for _, warning := range index.Lint() {
+		log.Printf("warning: %v\n", warning)
 	}
 
 	return index, nil
 }
 
+// QueryTimeoutWarning describes a version query that exceeded its timeout,
+// as distinct from a component that is not installed.
+func QueryTimeoutWarning(component string, query VersionQuery, result QueryResult) string {
+	return fmt.Sprintf("version query timed out after %v for %v: %v", result.Timeout, component, query)
+}
+
 // ScanOs analyzes operating system for any LTS concerns.
-func (o Index) ScanOs(t time.Time) (*string, error) {
+//
+// A configured version query takes precedence.
+// Otherwise, or when the query identifies no version,
+// the os-release file supplies the version,
+// falling back to the release codename when VERSION_ID is absent.
+func (o Index) ScanOs(ctx context.Context, t time.Time) (*string, error) {
 	identityOsP, err := RecognizeOs()
 
 	if err != nil {
@@ -317,36 +441,90 @@ func (o Index) ScanOs(t time.Time) (*string, error) {
 
 	query, ok := o.VersionQueries[identityOs]
 
-	if !ok {
-		log.Printf("no known version query command found for os: %v", identityOs)
-		return nil, nil
+	if ok {
+		result, err2 := query.Execute(ctx, o.QueryTimeout)
+
+		if err2 != nil {
+			return nil, err2
+		}
+
+		switch result.Status {
+		case QueryStatusFound:
+			if o.Debug {
+				log.Printf("detected os: %v v%v\n", identityOs, result.Version.String())
+			}
+
+			return ScanComponent(identityOs, result.Version, "", schedules, t), nil
+		case QueryStatusTimedOut:
+			warning := QueryTimeoutWarning(identityOs, query, *result)
+			return &warning, nil
+		default:
+			if o.Debug {
+				log.Printf("unable to identify version for os: %v: %v: %v; consulting os-release\n", identityOs, result.Status, result.Detail())
+			}
+		}
 	}
 
-	versionString, err := query.Execute()
+	osReleaseP, err := ReadOsRelease()
 
 	if err != nil {
 		return nil, err
 	}
 
-	if versionString == nil || *versionString == "" {
-		log.Fatalf("unable to identify version for os: %v", identityOs)
+	if osReleaseP == nil || osReleaseP.Product() != identityOs {
+		return nil, fmt.Errorf("unable to identify version for os: %v", identityOs)
+	}
+
+	return o.ScanOsRelease(*osReleaseP, t)
+}
+
+// ScanOsRelease analyzes an os-release identified distribution for any LTS concerns.
+//
+// The version comes from VERSION_ID,
+// falling back to the release codename when VERSION_ID is absent.
+func (o Index) ScanOsRelease(osRelease OsRelease, t time.Time) (*string, error) {
+	identityOs := osRelease.Product()
+	schedules, ok := o.components[identityOs]
+
+	if !ok {
+		log.Printf("no known support schedule found for os: %v", identityOs)
+		return nil, nil
 	}
 
-	versionP, err := semver.NewVersion(*versionString)
+	versionP, err := osRelease.Version()
 
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse semantic version: %v for os: %v", *versionString, identityOs)
+		return nil, fmt.Errorf("unable to parse os-release VERSION_ID for os: %v: %v", identityOs, err)
+	}
+
+	var codename string
+
+	if versionP == nil {
+		codename = osRelease.Codename()
+	}
+
+	if versionP == nil && codename == "" {
+		if osRelease.Derived() {
+			log.Printf("no release codename found for %v derivative: %v", identityOs, osRelease.ID)
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("os-release lacks VERSION_ID and VERSION_CODENAME for os: %v", identityOs)
 	}
 
 	if o.Debug {
-		log.Printf("detected os: %v v%v\n", identityOs, versionP.String())
+		if versionP != nil {
+			log.Printf("detected os: %v v%v\n", identityOs, versionP.String())
+		} else {
+			log.Printf("detected os: %v %v\n", identityOs, codename)
+		}
 	}
 
-	return ScanComponent(identityOs, versionP, "", schedules, t), nil
+	return ScanComponent(identityOs, versionP, codename, schedules, t), nil
 }
 
 // ScanKernel analyzes certain operating system kernels for any LTS concerns.
-func (o Index) ScanKernel(t time.Time) (*string, error) {
+func (o Index) ScanKernel(ctx context.Context, t time.Time) (*string, error) {
 	if !EnvironmentIsLinux {
 		return nil, nil
 	}
@@ -363,21 +541,22 @@ func (o Index) ScanKernel(t time.Time) (*string, error) {
 		log.Fatal("no known version query command found for product 'linux'")
 	}
 
-	versionString, err := query.Execute()
+	result, err := query.Execute(ctx, o.QueryTimeout)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if versionString == nil || *versionString == "" {
-		log.Fatalf("unable to identify linux kernel version")
+	switch result.Status {
+	case QueryStatusFound:
+	case QueryStatusTimedOut:
+		warning := QueryTimeoutWarning("linux", query, *result)
+		return &warning, nil
+	default:
+		return nil, fmt.Errorf("unable to identify linux kernel version: %v: %v", result.Status, result.Detail())
 	}
 
-	versionP, err := semver.NewVersion(*versionString)
-
-	if err != nil {
-		return nil, fmt.Errorf("unable to parse semantic linux kernel version: %v", *versionString)
-	}
+	versionP := result.Version
 
 	if o.Debug {
 		log.Printf("detected linux kernel: v%v\n", versionP.String())
@@ -389,7 +568,9 @@ func (o Index) ScanKernel(t time.Time) (*string, error) {
 // ScanApplication checks executables for non-LTS versions.
 //
 // If a semver cannot be queried, then the application is considered to not be installed.
-func (o Index) ScanApplication(app string, schedules []Schedule, t time.Time) (*string, error) {
+//
+// Queries exceeding their timeout yield a distinct warning.
+func (o Index) ScanApplication(ctx context.Context, app string, schedules []Schedule, t time.Time) (*string, error) {
 	if IsOperatingSystem(app) {
 		return nil, nil
 	}
@@ -404,48 +585,44 @@ func (o Index) ScanApplication(app string, schedules []Schedule, t time.Time) (*
 		return nil, nil
 	}
 
-	executable := query.Command[0]
-	executablePath, err := exec.LookPath(executable)
+	locationP, err := query.Locate()
 
 	if err != nil {
 		if o.Debug {
-			log.Printf("executable not found: %v for application %v; skipping\n", executable, app)
+			log.Printf("not found: %v for application %v; skipping\n", query, app)
 		}
 
 		return nil, nil
 	}
 
-	if o.Quiet && IsSystemExecutable(executablePath) {
+	if o.Quiet && query.Source() == VersionQuerySourceCommand && IsSystemExecutable(*locationP) {
 		if o.Debug {
-			log.Printf("executable: %v found in system path; skipping\n", executable)
+			log.Printf("executable: %v found in system path; skipping\n", *locationP)
 		}
 
 		return nil, nil
 	}
 
-	versionString, err := query.Execute()
+	result, err := query.Execute(ctx, o.QueryTimeout)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if versionString == nil || *versionString == "" {
+	switch result.Status {
+	case QueryStatusFound:
+	case QueryStatusTimedOut:
+		warning := QueryTimeoutWarning(app, query, *result)
+		return &warning, nil
+	default:
 		if o.Debug {
-			log.Printf("unable to identify version for app: %v\n", app)
+			log.Printf("unable to identify version for app: %v: %v: %v\n", app, result.Status, result.Detail())
 		}
 
 		return nil, nil
 	}
 
-	versionP, err := semver.NewVersion(*versionString)
-
-	if err != nil {
-		if o.Debug {
-			log.Printf("unable to parse semantic version: '%v' for app: '%v'\n", *versionString, app)
-		}
-
-		return nil, nil
-	}
+	versionP := result.Version
 
 	if o.Debug {
 		log.Printf("detected application: %v v%v\n", app, versionP.String())
@@ -455,19 +632,48 @@ func (o Index) ScanApplication(app string, schedules []Schedule, t time.Time) (*
 }
 
 // ScanApplications analyzes applications for any LTS concerns.
-func (o Index) ScanApplications(t time.Time) ([]string, error) {
-	var warnings []string
+//
+// Up to Jobs version queries run concurrently.
+//
+// In AllInstalls mode, every installation of each command query executable is checked.
+func (o Index) ScanApplications(ctx context.Context, t time.Time) ([]string, error) {
+	var apps []string
 
-	for executable, schedules := range o.components {
-		warning, err := o.ScanApplication(executable, schedules, t)
+	for app := range o.components {
+		apps = append(apps, app)
+	}
 
-		if err != nil {
-			return nil, err
+	sort.Strings(apps)
+
+	results := make([][]string, len(apps))
+	errs := make([]error, len(apps))
+
+	RunJobs(len(apps), o.Jobs, func(i int) {
+		app := apps[i]
+		query, ok := o.VersionQueries[app]
+
+		if o.AllInstalls && ok && !IsOperatingSystem(app) && query.Source() == VersionQuerySourceCommand {
+			results[i], errs[i] = o.ScanApplicationInstalls(ctx, app, o.components[app], t)
+			return
 		}
 
+		warning, err := o.ScanApplication(ctx, app, o.components[app], t)
+
 		if warning != nil {
-			warnings = append(warnings, *warning)
+			results[i] = []string{*warning}
+		}
+
+		errs[i] = err
+	})
+
+	var warnings []string
+
+	for i, appWarnings := range results {
+		if errs[i] != nil {
+			return nil, errs[i]
 		}
+
+		warnings = append(warnings, appWarnings...)
 	}
 
 	return warnings, nil
@@ -481,6 +687,22 @@ type DockerWarnings struct {
 	// Warninges denotes any dead base images.
 	Warnings []string
 
+	// UnpinnedTags reports images that use floating tags.
+	UnpinnedTags bool
+
+	// Extract collects the images referenced by a file.
+	//
+	// A false result indicates an unsupported file.
+	Extract func(pth string) ([]Image, bool, error)
+
+	// Resolve scans the registry content of an image, when enabled.
+	//
+	// nil indicates tag based scanning only.
+	Resolve func(image Image) ([]string, error)
+
+	// root denotes the project directory.
+	root string
+
 	// t denotes the current timestamp.
 	t time.Time
 
@@ -516,18 +738,28 @@ func Ignore(pth string) bool {
 // DockerfilePattern matches Docker image definition files.
 var DockerfilePattern = regexp.MustCompile(`(Dockerfile.*)|(.*\.[Dd]ockerfile)`)
 
-// DockerfileBaseImagePattern extracts base image names from Dockerfiles.
-var DockerfileBaseImagePattern = regexp.MustCompile(`^FROM\s+((?P<registry>.+)/)?(?P<image>[^:\s]+)(:(?P<tag>[^\s]+))?(\s+as\s+(?P<stage>[^\s]+))?$`)
-
 // Image models a Docker base image identifier.
 type Image struct {
+	// Registry denotes any registry host and namespace.
 	Registry string
 
+	// Name denotes the repository base name.
 	Name string
 
+	// Tag denotes the image tag.
 	Tag string
 
+	// Digest denotes any content digest, such as sha256:...
+	Digest string
+
+	// Stage denotes any build stage name.
 	Stage string
+
+	// Platform denotes any --platform value.
+	Platform string
+
+	// Line denotes the one-based line number of the FROM instruction.
+	Line int
 }
 
 // String formats Docker image identifiers.
@@ -544,6 +776,10 @@ func (o Image) String() string {
 		buffer.WriteString(fmt.Sprintf(":%s", o.Tag))
 	}
 
+	if o.Digest != "" {
+		buffer.WriteString(fmt.Sprintf("@%s", o.Digest))
+	}
+
 	if o.Stage != "" {
 		buffer.WriteString(fmt.Sprintf(" as %s", o.Stage))
 	}
@@ -551,22 +787,15 @@ func (o Image) String() string {
 	return buffer.String()
 }
 
-// Dockerfile models a Docker image configuration.
-type Dockerfile struct {
-	Stages []string
-
-	BaseImages []Image
-}
-
 // ExtractBaseImages collects the base image names
 // for all the build stages of a Dockerfile path.
-func ExtractBaseImages(pth string) ([]Image, error) {
-	var dockerfile Dockerfile
-
+//
+// buildArgs overrides ARG defaults.
+func ExtractBaseImages(pth string, buildArgs map[string]string) ([]Image, error) {
 	f, err := os.Open(pth)
 
 	if err != nil {
-		return dockerfile.BaseImages, err
+		return nil, err
 	}
 
 	defer func() {
@@ -575,65 +804,91 @@ func ExtractBaseImages(pth string) ([]Image, error) {
 		}
 	}()
 
-	scanner := bufio.NewScanner(f)
+	instructions, err := ParseDockerfile(f)
 
-	registryIndex := DockerfileBaseImagePattern.SubexpIndex("registry")
-	imageIndex := DockerfileBaseImagePattern.SubexpIndex("image")
-	tagIndex := DockerfileBaseImagePattern.SubexpIndex("tag")
-	stageIndex := DockerfileBaseImagePattern.SubexpIndex("stage")
+	if err != nil {
+		return nil, err
+	}
 
-	var rawImages []Image
+	images, err := DockerfileBaseImages(instructions, buildArgs)
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		match := DockerfileBaseImagePattern.FindStringSubmatch(line)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", pth, err)
+	}
 
-		if len(match) <= imageIndex {
-			continue
+	return images, nil
+}
+
+// CheckImage analyzes an image reference for any LTS concerns.
+//
+// location denotes the file and line of the reference.
+func (o *DockerWarnings) CheckImage(image Image, location string) {
+	if o.Debug {
+		log.Printf("detected docker image '%v': %v\n", image, location)
+	}
+
+	if o.Resolve != nil && image.Name != "scratch" {
+		resolved, err := o.Resolve(image)
+
+		if err == nil {
+			for _, warning := range resolved {
+				o.Warnings = append(o.Warnings, fmt.Sprintf("%v at %v", warning, location))
+			}
+
+			return
 		}
 
-		image := Image{
-			Registry: match[registryIndex],
-			Name:     match[imageIndex],
-			Tag:      "latest",
+		log.Printf("warning: unable to resolve image '%v' via registry, falling back to tag: %v\n", image, err)
+	}
+
+	if image.Tag == "" {
+		if o.Debug {
+			log.Printf("skipping untagged docker image: '%v': %v\n", image, location)
 		}
 
-		if len(match) > tagIndex {
-			tag := match[tagIndex]
+		return
+	}
 
-			if tag != "" {
-				image.Tag = match[tagIndex]
+	for _, imageComponent := range image.Components() {
+		schedules, ok := o.components[imageComponent.Product]
+
+		if !ok {
+			if o.Debug {
+				log.Printf("skipping unknown docker image component %v: '%v': %v\n", imageComponent.Product, image, location)
 			}
 
-			if len(match) > stageIndex {
-				stage := match[stageIndex]
+			continue
+		}
 
-				if stage != "" {
-					image.Stage = stage
-					dockerfile.Stages = append(dockerfile.Stages, stage)
-				}
-			}
+		if o.Debug {
+			log.Printf("detected docker image component %v: '%v': %v\n", imageComponent.Name(), image, location)
 		}
 
-		rawImages = append(rawImages, image)
-	}
+		if imageComponent.Floating != "" {
+			schedule := ResolveFloatingTag(imageComponent.Product, imageComponent.Floating, schedules)
 
-	for _, rawImage := range rawImages {
-		var stageBorn bool
+			if schedule == nil {
+				if o.Debug {
+					log.Printf("skipping unresolvable floating tag %v: '%v': %v\n", imageComponent.Name(), image, location)
+				}
 
-		for _, stage := range dockerfile.Stages {
-			if rawImage.Name == stage {
-				stageBorn = true
-				break
+				continue
+			}
+
+			version := schedule.Version
+			imageComponent.Version = &version
+
+			if o.UnpinnedTags {
+				o.Warnings = append(o.Warnings, fmt.Sprintf("unpinned image tag %v:%v currently resolves to %v %v at %v", image.Name, image.Tag, imageComponent.Product, version.Original(), location))
 			}
 		}
 
-		if !stageBorn {
-			dockerfile.BaseImages = append(dockerfile.BaseImages, rawImage)
+		warningP := ScanPinnedComponent(imageComponent.Product, imageComponent.Version, imageComponent.Codename, schedules, o.t)
+
+		if warningP != nil {
+			o.Warnings = append(o.Warnings, fmt.Sprintf("%v at %v", *warningP, location))
 		}
 	}
-
-	return dockerfile.BaseImages, nil
 }
 
 // Walk is a callback for filepath.Walk to lint shell scripts.
@@ -656,96 +911,102 @@ func (o *DockerWarnings) Walk(pth string, _ os.FileInfo, err error) error {
 		return nil
 	}
 
-	match := DockerfilePattern.MatchString(pth)
+	images, ok, err := o.Extract(pth)
 
-	if !match {
+	if !ok {
 		return nil
 	}
 
-	images, err := ExtractBaseImages(pth)
-
 	if err != nil {
 		return err
 	}
 
-	for _, image := range images {
-		if o.Debug {
-			log.Printf("detected dockerfile base image '%v': %v\n", image, pth)
-		}
-
-		name := image.Name
-
-		if strings.HasSuffix(name, "-slim") {
-			if o.Debug {
-				log.Printf("trimming -slim suffix from base image name: %v\n", name)
-			}
-		}
+	rel, err := filepath.Rel(o.root, pth)
 
-		name = strings.TrimSuffix(image.Name, "-slim")
-
-		component, ok := o.components[name]
+	if err != nil {
+		rel = pth
+	}
 
-		if !ok {
-			if o.Debug {
-				log.Printf("skipping unknown docker image operating system: '%v': %v\n", image, pth)
-			}
+	for _, image := range images {
+		o.CheckImage(image, fmt.Sprintf("%v:%d", rel, image.Line))
+	}
 
-			continue
-		}
+	return nil
+}
 
-		var versionP *semver.Version
+// scanImages walks the project directory,
+// analyzing the images that extract collects from each file.
+//
+// In ResolveImages mode, images are read from their registries,
+// except in offline mode.
+func (o Index) scanImages(ctx context.Context, t time.Time, extract func(pth string) ([]Image, bool, error)) ([]string, error) {
+	dockerWarnings := DockerWarnings{
+		Debug:        o.Debug,
+		UnpinnedTags: o.UnpinnedTags,
+		Extract:      extract,
+		root:         o.configDir,
+		components:   o.components,
+		t:            t,
+	}
 
-		tag := image.Tag
+	if o.ResolveImages && !o.Offline {
+		client := NewRegistryClient()
 
-		if strings.HasSuffix(tag, "-slim") {
-			if o.Debug {
-				log.Printf("trimming -slim suffix from base image tag: %v\n", tag)
-			}
+		dockerWarnings.Resolve = func(image Image) ([]string, error) {
+			return o.ScanRegistryImage(ctx, client, image, t)
 		}
+	}
 
-		tag = strings.TrimSuffix(tag, "-slim")
-
-		if vP, err := semver.NewVersion(tag); err == nil {
-			versionP = vP
-		}
+	if err2 := filepath.Walk(o.configDir, dockerWarnings.Walk); err2 != nil {
+		return dockerWarnings.Warnings, err2
+	}
 
-		warningP := ScanComponent(name, versionP, tag, component, o.t)
+	return dockerWarnings.Warnings, nil
+}
 
-		if warningP != nil {
-			o.Warnings = append(o.Warnings, *warningP)
+// ScanDockerfiles analyzes Dockerfile base images,
+// within the project directory.
+func (o Index) ScanDockerfiles(ctx context.Context, t time.Time) ([]string, error) {
+	return o.scanImages(ctx, t, func(pth string) ([]Image, bool, error) {
+		if !DockerfilePattern.MatchString(pth) {
+			return nil, false, nil
 		}
-	}
 
-	return nil
+		images, err := ExtractBaseImages(pth, o.BuildArgs)
+		return images, true, err
+	})
 }
 
-// ScanDockerfiles analyzes Dockerfiles.
-func (o Index) ScanDockerfiles(t time.Time) ([]string, error) {
-	dockerWarnings := DockerWarnings{
-		Debug:      o.Debug,
-		components: o.components,
-		t:          t,
-	}
-
-	cwd, err := os.Getwd()
+// ScanComposeFiles analyzes the service images of Compose files,
+// within the project directory.
+func (o Index) ScanComposeFiles(ctx context.Context, t time.Time) ([]string, error) {
+	return o.scanImages(ctx, t, ExtractComposeImages)
+}
 
-	if err != nil {
-		return nil, err
-	}
+// ScanKubernetesManifests analyzes the container images of Kubernetes manifests
+// and Helm chart values files,
+// within the project directory.
+func (o Index) ScanKubernetesManifests(ctx context.Context, t time.Time) ([]string, error) {
+	return o.scanImages(ctx, t, ExtractKubernetesImages)
+}
 
-	if err2 := filepath.Walk(cwd, dockerWarnings.Walk); err2 != nil {
-		return dockerWarnings.Warnings, err2
+// WithScanTimeout bounds a scan context by ScanTimeout, when set.
+func (o Index) WithScanTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.ScanTimeout == 0 {
+		return context.WithCancel(ctx)
 	}
 
-	return dockerWarnings.Warnings, nil
+	return context.WithTimeout(ctx, time.Duration(o.ScanTimeout))
 }
 
 // Scan generates reports.
-func (o Index) Scan() ([]string, error) {
+//
+// On Linux, installed packages are scanned too, except in quiet mode.
+func (o Index) Scan(ctx context.Context) ([]string, error) {
 	var warnings []string
 	tNow := time.Now()
 	t := tNow.AddDate(0, o.LeadMonths, 0)
-	warningOs, err := o.ScanOs(t)
+	warningOs, err := o.ScanOs(ctx, t)
 
 	if err != nil {
 		return nil, err
@@ -755,7 +1016,7 @@ func (o Index) Scan() ([]string, error) {
 		warnings = append(warnings, *warningOs)
 	}
 
-	warningKernel, err := o.ScanKernel(t)
+	warningKernel, err := o.ScanKernel(ctx, t)
 
 	if err != nil {
 		return nil, err
@@ -765,36 +1026,82 @@ func (o Index) Scan() ([]string, error) {
 		warnings = append(warnings, *warningKernel)
 	}
 
-	resultsApplications, err := o.ScanApplications(t)
+	resultsApplications, err := o.ScanApplications(ctx, t)
 
 	if err != nil {
 		return nil, err
 	}
 
 	warnings = append(warnings, resultsApplications...)
-	resultsDockerfiles, err := o.ScanDockerfiles(t)
+
+	if EnvironmentIsLinux && o.Packages {
+		resultsPackages, err2 := o.ScanPackages(ctx, Root{FS: os.DirFS("/"), Dir: "/"}, t)
+
+		if err2 != nil {
+			return nil, err2
+		}
+
+		warnings = append(warnings, resultsPackages...)
+	}
+
+	resultsDockerfiles, err := o.ScanDockerfiles(ctx, t)
 
 	if err != nil {
 		return nil, err
 	}
 
 	warnings = append(warnings, resultsDockerfiles...)
-	return warnings, nil
+	resultsCompose, err := o.ScanComposeFiles(ctx, t)
+
+	if err != nil {
+		return nil, err
+	}
+
+	warnings = append(warnings, resultsCompose...)
+	resultsKubernetes, err := o.ScanKubernetesManifests(ctx, t)
+
+	if err != nil {
+		return nil, err
+	}
+
+	warnings = append(warnings, resultsKubernetes...)
+	resultsPins, err := o.ScanPinFiles(t)
+
+	if err != nil {
+		return nil, err
+	}
+
+	warnings = append(warnings, resultsPins...)
+	resultsManifests, err := o.ScanManifests(t)
+
+	if err != nil {
+		return nil, err
+	}
+
+	warnings = append(warnings, resultsManifests...)
+	resultsLockfiles, err := o.ScanLockfiles(t)
+
+	if err != nil {
+		return nil, err
+	}
+
+	warnings = append(warnings, resultsLockfiles...)
+	return UniqueWarnings(warnings), nil
 }
 
 // Clean removes artifacts created during cicada runs.
-func Clean() error {
-	cwd, err := os.Getwd()
+func Clean(options LoadOptions) error {
+	configPathP, projectDirP, err := LocateConfig(options.ConfigPath)
 
 	if err != nil {
 		return err
 	}
 
-	indexCacheDirPath, err := IndexCacheDirPath(cwd)
+	policyP, err := BootstrapCachePolicy(configPathP, *projectDirP, options)
 
 	if err != nil {
 		return err
 	}
 
-	return os.RemoveAll(*indexCacheDirPath)
+	return os.RemoveAll(policyP.Dir)
 }
@@ -4,11 +4,42 @@ import (
 	"github.com/mcandre/cicada"
 	"gopkg.in/yaml.v3"
 
+	"os"
+	"path/filepath"
 	"reflect"
 	"regexp"
 	"testing"
 )
 
+// loadProject loads an offline project from files,
+// keyed on path relative to the project directory.
+//
+// Schedules come from the lc directory source.
+func loadProject(t *testing.T, files map[string]string) *cicada.Index {
+	dir := t.TempDir()
+	files["cicada.yaml"] = "sources:\n  - kind: directory\n    path: lc\nversion_queries: {}\n"
+
+	for rel, content := range files {
+		pth := filepath.Join(dir, rel)
+
+		if err := os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(pth, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	index, err := cicada.Load(cicada.LoadOptions{ConfigPath: filepath.Join(dir, "cicada.yaml")})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return index
+}
+
 func TestIndexYAMLCodec(t *testing.T) {
 	index := cicada.Index{
 		Debug: true,
@@ -35,3 +66,35 @@ func TestIndexYAMLCodec(t *testing.T) {
 		t.Errorf("Expected decoded index2: %v to equal original query: %v", index2, index)
 	}
 }
+
+func TestIndexFormats(t *testing.T) {
+	contentJSON := `{"lead_months": 2, "version_queries": {"go": {"command": ["go", "version"], "pattern": "^go version go(?P<Version>[0-9\\.]+) .+$"}}}`
+
+	contentTOML := `lead_months = 2
+
+[version_queries]
+go = {command = ["go", "version"], pattern = "^go version go(?P<Version>[0-9\\.]+) .+$"}
+`
+
+	var indexJSON cicada.Index
+	if err := cicada.DecodeConfig("cicada.json", []byte(contentJSON), &indexJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	var indexTOML cicada.Index
+	if err := cicada.DecodeConfig(".cicada.toml", []byte(contentTOML), &indexTOML); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(indexTOML, indexJSON) {
+		t.Errorf("Expected TOML index: %v to equal JSON index: %v", indexTOML, indexJSON)
+	}
+
+	if err := cicada.DecodeConfig("cicada.json", []byte(`{"lead_month": 2}`), &indexJSON); err == nil {
+		t.Errorf("Expected unknown JSON field to be rejected")
+	}
+
+	if err := cicada.DecodeConfig("cicada.toml", []byte(`lead_month = 2`), &indexTOML); err == nil {
+		t.Errorf("Expected unknown TOML field to be rejected")
+	}
+}
@@ -0,0 +1,257 @@
+package cicada
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// InitTemplate renders tailored cicada configurations.
+var InitTemplate = template.Must(template.New("cicada.yaml").Parse(`---
+# Generated by cicada init.
+#
+# Keys here merge over the built-in defaults.
+# For more detail, see https://github.com/mcandre/cicada/blob/main/CONFIGURATION.md
+#
+# When enabled, ` + "`debug`" + ` emits additional logs.
+#
+# debug: true
+#
+# When enabled, ` + "`quiet`" + ` skips scanning of
+# any software components found in system binary directories.
+#
+# quiet: true
+#
+# The ` + "`lead_months`" + ` setting controls the early warning timing
+# relative to the formal support timeline termination date.
+#
+# lead_months: {{.LeadMonths}}
+{{- if .Dockerfiles}}
+#
+# Dockerfile base images are scanned automatically:
+#
+{{- range .Dockerfiles}}
+# * {{.}}
+{{- end}}
+{{- end}}
+#
+# Detected operating system: {{.Os}}
+#
+version_queries:
+{{- range .Queries}}
+  {{.Product}}:
+{{- if .Command}}
+    command: [{{.Command}}]
+{{- end}}
+{{- if .File}}
+    file: {{.File}}
+{{- end}}
+{{- if .Env}}
+    env: {{.Env}}
+{{- end}}
+{{- if .Pattern}}
+    pattern: {{.Pattern}}
+{{- end}}
+{{- if .Timeout}}
+    timeout: {{.Timeout}}
+{{- end}}
+{{- else}} {}
+{{- end}}
+`))
+
+// InitQuery models a rendered version query.
+type InitQuery struct {
+	// Product denotes an endoflife.date product name.
+	Product string
+
+	// Command denotes a YAML flow sequence body.
+	Command string
+
+	// File denotes a YAML double quoted string.
+	File string
+
+	// Env denotes a YAML double quoted string.
+	Env string
+
+	// Pattern denotes a YAML double quoted string.
+	Pattern string
+
+	// Timeout denotes a duration, such as 30s.
+	Timeout string
+}
+
+// InitConfig models the facts gathered for a tailored configuration.
+type InitConfig struct {
+	// Os denotes the current operating system.
+	Os string
+
+	// LeadMonths denotes the default lead time.
+	LeadMonths int
+
+	// Dockerfiles denotes Docker image definition paths,
+	// relative to the project directory.
+	Dockerfiles []string
+
+	// Queries denotes the applicable version queries.
+	Queries []InitQuery
+}
+
+// NewInitQuery formats a version query for rendering.
+func NewInitQuery(product string, query VersionQuery) InitQuery {
+	var args []string
+
+	for _, arg := range query.Command {
+		args = append(args, strconv.Quote(arg))
+	}
+
+	initQuery := InitQuery{
+		Product: product,
+		Command: strings.Join(args, ", "),
+	}
+
+	if query.File != "" {
+		initQuery.File = strconv.Quote(query.File)
+	}
+
+	if query.Env != "" {
+		initQuery.Env = strconv.Quote(query.Env)
+	}
+
+	if query.Pattern != nil {
+		initQuery.Pattern = strconv.Quote(query.Pattern.String())
+	}
+
+	if query.Timeout != 0 {
+		initQuery.Timeout = query.Timeout.String()
+	}
+
+	return initQuery
+}
+
+// GenerateConfig renders a cicada configuration
+// tailored to the current machine and the project in dir.
+//
+// The configuration includes built-in version queries
+// for the current operating system,
+// and for any applications located on this machine.
+func GenerateConfig(dir string) ([]byte, error) {
+	defaults, err := NewDefaultIndex()
+
+	if err != nil {
+		return nil, err
+	}
+
+	identityOsP, err := RecognizeOs()
+
+	if err != nil {
+		return nil, err
+	}
+
+	config := InitConfig{
+		Os:         *identityOsP,
+		LeadMonths: DefaultLeadMonths,
+	}
+
+	var products []string
+
+	for product := range defaults.VersionQueries {
+		products = append(products, product)
+	}
+
+	sort.Strings(products)
+
+	for _, product := range products {
+		query := defaults.VersionQueries[product]
+
+		switch {
+		case product == config.Os:
+		case product == "linux" && EnvironmentIsLinux:
+		case IsOperatingSystem(product):
+			continue
+		default:
+			if _, err2 := query.Locate(); err2 != nil {
+				continue
+			}
+		}
+
+		config.Queries = append(config.Queries, NewInitQuery(product, query))
+	}
+
+	err = filepath.Walk(dir, func(pth string, fi os.FileInfo, err2 error) error {
+		if err2 != nil {
+			return err2
+		}
+
+		if Ignore(pth) || fi.IsDir() || !DockerfilePattern.MatchString(fi.Name()) {
+			return nil
+		}
+
+		rel, err3 := filepath.Rel(dir, pth)
+
+		if err3 != nil {
+			return err3
+		}
+
+		config.Dockerfiles = append(config.Dockerfiles, rel)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	if err2 := InitTemplate.Execute(&buf, config); err2 != nil {
+		return nil, err2
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Init writes a tailored cicada configuration
+// to the current working directory.
+//
+// Existing configurations are preserved.
+func Init() (*string, error) {
+	cwd, err := os.Getwd()
+
+	if err != nil {
+		return nil, err
+	}
+
+	existingP, err := FindConfigInDir(cwd)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if existingP != nil {
+		return nil, fmt.Errorf("refusing to overwrite existing configuration: %v", *existingP)
+	}
+
+	pthP, err := IndexCacheConfigPath(cwd)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pth := *pthP
+
+	contentYAML, err := GenerateConfig(cwd)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err2 := os.WriteFile(pth, contentYAML, 0644); err2 != nil {
+		return nil, err2
+	}
+
+	return &pth, nil
+}
@@ -0,0 +1,114 @@
+package cicada_test
+
+import (
+	"github.com/mcandre/cicada"
+
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(dir, "svc"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "svc", "Dockerfile"), []byte("FROM alpine:3.16\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	contentYAML, err := cicada.GenerateConfig(dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var index cicada.Index
+
+	if err2 := cicada.DecodeYAMLStrict(contentYAML, &index); err2 != nil {
+		t.Fatalf("Expected generated configuration to decode strictly, got: %v\n%s", err2, contentYAML)
+	}
+
+	if err2 := index.ValidateVersionQueries(); err2 != nil {
+		t.Error(err2)
+	}
+
+	if !strings.Contains(string(contentYAML), "# * "+filepath.Join("svc", "Dockerfile")) {
+		t.Errorf("Expected generated configuration to list svc/Dockerfile, got:\n%s", contentYAML)
+	}
+}
+
+func TestInitTemplateDefaults(t *testing.T) {
+	defaults, err := cicada.NewDefaultIndex()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := cicada.InitConfig{Os: "debian", LeadMonths: cicada.DefaultLeadMonths}
+	var products []string
+
+	for product := range defaults.VersionQueries {
+		products = append(products, product)
+	}
+
+	defaults.VersionQueries["widget"] = cicada.VersionQuery{
+		Command: []string{"widget", "--version"},
+		Timeout: cicada.Duration(30 * time.Second),
+	}
+
+	sort.Strings(products)
+	products = append(products, "widget")
+
+	for _, product := range products {
+		config.Queries = append(config.Queries, cicada.NewInitQuery(product, defaults.VersionQueries[product]))
+	}
+
+	var buf bytes.Buffer
+
+	if err2 := cicada.InitTemplate.Execute(&buf, config); err2 != nil {
+		t.Fatal(err2)
+	}
+
+	var index cicada.Index
+
+	if err2 := cicada.DecodeYAMLStrict(buf.Bytes(), &index); err2 != nil {
+		t.Fatal(err2)
+	}
+
+	for _, product := range products {
+		expected, actual := defaults.VersionQueries[product], index.VersionQueries[product]
+
+		if !reflect.DeepEqual(actual.Command, expected.Command) || actual.File != expected.File || actual.Env != expected.Env || actual.Timeout != expected.Timeout {
+			t.Errorf("Expected %v query to round trip as %v, got: %v", product, expected, actual)
+		}
+
+		if (actual.Pattern == nil) != (expected.Pattern == nil) || (expected.Pattern != nil && actual.Pattern.String() != expected.Pattern.String()) {
+			t.Errorf("Expected %v pattern to round trip as %v, got: %v", product, expected.Pattern, actual.Pattern)
+		}
+	}
+}
+
+func TestInit(t *testing.T) {
+	t.Chdir(t.TempDir())
+	pthP, err := cicada.Init()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err2 := os.Stat(*pthP); err2 != nil {
+		t.Fatal(err2)
+	}
+
+	if _, err2 := cicada.Init(); err2 == nil {
+		t.Errorf("Expected init to refuse overwriting %v", *pthP)
+	}
+}
@@ -0,0 +1,182 @@
+package cicada
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// VersionManager models a tool that installs multiple versions of runtimes.
+type VersionManager struct {
+	// Name denotes the tool name.
+	Name string
+
+	// RootEnv denotes the environment variable overriding the root directory.
+	RootEnv string
+
+	// RootBase denotes the default root directory, relative to the home directory.
+	RootBase string
+
+	// BinGlob denotes the executable directories, relative to the root directory.
+	BinGlob string
+}
+
+// VersionManagers enumerates the supported version managers.
+var VersionManagers = []VersionManager{
+	{Name: "asdf", RootEnv: "ASDF_DATA_DIR", RootBase: ".asdf", BinGlob: filepath.Join("installs", "*", "*", "bin")},
+	{Name: "goenv", RootEnv: "GOENV_ROOT", RootBase: ".goenv", BinGlob: filepath.Join("versions", "*", "bin")},
+	{Name: "nvm", RootEnv: "NVM_DIR", RootBase: ".nvm", BinGlob: filepath.Join("versions", "node", "*", "bin")},
+	{Name: "pyenv", RootEnv: "PYENV_ROOT", RootBase: ".pyenv", BinGlob: filepath.Join("versions", "*", "bin")},
+	{Name: "rbenv", RootEnv: "RBENV_ROOT", RootBase: ".rbenv", BinGlob: filepath.Join("versions", "*", "bin")},
+	{Name: "sdkman", RootEnv: "SDKMAN_DIR", RootBase: ".sdkman", BinGlob: filepath.Join("candidates", "*", "*", "bin")},
+}
+
+// Root resolves the version manager root directory.
+//
+// Blank indicates an unknown home directory.
+func (o VersionManager) Root() string {
+	if root := os.Getenv(o.RootEnv); root != "" {
+		return root
+	}
+
+	home, err := os.UserHomeDir()
+
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, o.RootBase)
+}
+
+// BinDirs lists the executable directories of installed versions.
+func (o VersionManager) BinDirs() []string {
+	root := o.Root()
+
+	if root == "" {
+		return nil
+	}
+
+	dirs, err := filepath.Glob(filepath.Join(root, o.BinGlob))
+
+	if err != nil {
+		return nil
+	}
+
+	return dirs
+}
+
+// InstallDirs lists the directories searched for installations:
+// each PATH entry, followed by version manager executable directories.
+//
+// Version manager shim directories are omitted,
+// as shims merely dispatch to an installation.
+func InstallDirs() []string {
+	var dirs []string
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir != "" && filepath.Base(dir) != "shims" {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	for _, manager := range VersionManagers {
+		dirs = append(dirs, manager.BinDirs()...)
+	}
+
+	return dirs
+}
+
+// FindExecutables locates every installation of an executable in InstallDirs.
+//
+// Symlinks to the same installation are reported once.
+func FindExecutables(name string) []string {
+	var executables []string
+	seen := make(map[string]bool)
+
+	for _, dir := range InstallDirs() {
+		executable, err := exec.LookPath(filepath.Join(dir, name))
+
+		if err != nil {
+			continue
+		}
+
+		resolved, err := filepath.EvalSymlinks(executable)
+
+		if err != nil {
+			resolved = executable
+		}
+
+		if seen[resolved] {
+			continue
+		}
+
+		seen[resolved] = true
+		executables = append(executables, executable)
+	}
+
+	return executables
+}
+
+// WithExecutable substitutes the command executable of a version query.
+func (o VersionQuery) WithExecutable(executable string) VersionQuery {
+	command := append([]string{executable}, o.Command[1:]...)
+	o.Command = command
+	return o
+}
+
+// ScanApplicationInstalls checks every installation of an executable for non-LTS versions.
+//
+// Installations are checked in FindExecutables order,
+// so the first warning names the installation that wins PATH resolution.
+// Each warning names the install path.
+func (o Index) ScanApplicationInstalls(ctx context.Context, app string, schedules []Schedule, t time.Time) ([]string, error) {
+	query := o.VersionQueries[app]
+	executables := FindExecutables(query.Command[0])
+	var warnings []string
+
+	for _, executable := range executables {
+		if o.Quiet && IsSystemExecutable(executable) {
+			if o.Debug {
+				log.Printf("executable: %v found in system path; skipping\n", executable)
+			}
+
+			continue
+		}
+
+		installQuery := query.WithExecutable(executable)
+		result, err := installQuery.Execute(ctx, o.QueryTimeout)
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch result.Status {
+		case QueryStatusFound:
+		case QueryStatusTimedOut:
+			warnings = append(warnings, QueryTimeoutWarning(app, installQuery, *result))
+			continue
+		default:
+			if o.Debug {
+				log.Printf("unable to identify version for app: %v at %v: %v: %v\n", app, executable, result.Status, result.Detail())
+			}
+
+			continue
+		}
+
+		if o.Debug {
+			log.Printf("detected application: %v v%v at %v\n", app, result.Version.String(), executable)
+		}
+
+		warning := ScanComponent(app, result.Version, "", schedules, t)
+
+		if warning != nil {
+			warnings = append(warnings, fmt.Sprintf("%v at %v", *warning, executable))
+		}
+	}
+
+	return warnings, nil
+}
@@ -0,0 +1,133 @@
+package cicada_test
+
+import (
+	"github.com/Masterminds/semver"
+	"github.com/mcandre/cicada"
+
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestFindExecutables(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts unavailable")
+	}
+
+	pathDir := t.TempDir()
+	shimsDir := filepath.Join(t.TempDir(), "shims")
+	pyenvRoot := t.TempDir()
+	pyenvBinDir := filepath.Join(pyenvRoot, "versions", "3.7.17", "bin")
+
+	for dir, version := range map[string]string{
+		pathDir:     "3.12.1",
+		shimsDir:    "3.12.1",
+		pyenvBinDir: "3.7.17",
+	} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, "widget"), []byte("#!/bin/sh\necho "+version+"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Setenv("PATH", shimsDir+string(os.PathListSeparator)+pathDir)
+	t.Setenv("PYENV_ROOT", pyenvRoot)
+
+	executables := cicada.FindExecutables("widget")
+
+	if len(executables) != 2 || executables[0] != filepath.Join(pathDir, "widget") || executables[1] != filepath.Join(pyenvBinDir, "widget") {
+		t.Fatalf("Expected PATH and pyenv installations, sans shims, got: %v", executables)
+	}
+
+	query := cicada.VersionQuery{Command: []string{"widget"}}
+	result, err := query.WithExecutable(executables[1]).Execute(context.Background(), cicada.DefaultQueryTimeout)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Status != cicada.QueryStatusFound || result.Version.String() != "3.7.17" {
+		t.Errorf("Expected pyenv installation 3.7.17, got: %v %v", result.Status, result.Version)
+	}
+}
+
+func TestScanApplicationInstalls(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts unavailable")
+	}
+
+	root := t.TempDir()
+	firstDir := filepath.Join(root, "z")
+	secondDir := filepath.Join(root, "a")
+
+	for dir, version := range map[string]string{
+		firstDir:  "3.7.17",
+		secondDir: "3.6.15",
+	} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, "widget"), []byte("#!/bin/sh\necho "+version+"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Setenv("PATH", firstDir+string(os.PathListSeparator)+secondDir)
+	t.Setenv("HOME", t.TempDir())
+
+	for _, manager := range cicada.VersionManagers {
+		t.Setenv(manager.RootEnv, "")
+	}
+
+	var schedules []cicada.Schedule
+
+	for version, expiration := range map[string]string{
+		"3.7": "2023-06-27",
+		"3.6": "2021-12-23",
+	} {
+		versionP, err := semver.NewVersion(version)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		exp, err := time.Parse(cicada.RFC3339DateFormat, expiration)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		schedules = append(schedules, cicada.Schedule{Name: "widget", Version: *versionP, Expiration: &exp})
+	}
+
+	index := cicada.Index{
+		VersionQueries: map[string]cicada.VersionQuery{
+			"widget": {Command: []string{"widget"}},
+		},
+		QueryTimeout: cicada.DefaultQueryTimeout,
+	}
+
+	t2025 := time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)
+	warnings, err := index.ScanApplicationInstalls(context.Background(), "widget", schedules, t2025)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"end of life for widget 3.7.17 on 2023-06-27 at " + filepath.Join(firstDir, "widget"),
+		"end of life for widget 3.6.15 on 2021-12-23 at " + filepath.Join(secondDir, "widget"),
+	}
+
+	if !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("Expected warnings in PATH order %v, got: %v", expected, warnings)
+	}
+}
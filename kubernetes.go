@@ -0,0 +1,244 @@
+package cicada
+
+import (
+	"gopkg.in/yaml.v3"
+
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// KubernetesManifestPattern matches YAML files that may hold Kubernetes manifests.
+var KubernetesManifestPattern = regexp.MustCompile(`\.ya?ml$`)
+
+// KubernetesContainerKeys lists the pod spec keys of container lists.
+var KubernetesContainerKeys = []string{"containers", "initContainers", "ephemeralContainers"}
+
+// HelmChartBase denotes the Helm chart metadata file.
+const HelmChartBase = "Chart.yaml"
+
+// HelmValuesPattern matches Helm values files, such as values.yaml and values-prod.yaml.
+var HelmValuesPattern = regexp.MustCompile(`^values.*\.ya?ml$`)
+
+// collectContainerImages gathers the image fields of container lists,
+// at any depth, covering Pods, workload templates, CronJob job templates, and Lists.
+func collectContainerImages(node *yaml.Node, images *[]Image) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+
+			for _, containerKey := range KubernetesContainerKeys {
+				if key.Value != containerKey || value.Kind != yaml.SequenceNode {
+					continue
+				}
+
+				for _, container := range value.Content {
+					imageNode := YAMLMappingValue(container, "image")
+
+					if imageNode == nil || imageNode.Kind != yaml.ScalarNode || imageNode.Value == "" {
+						continue
+					}
+
+					image := ParseImageReference(imageNode.Value)
+					image.Line = imageNode.Line
+
+					if image.Tag == "" && image.Digest == "" {
+						image.Tag = "latest"
+					}
+
+					*images = append(*images, image)
+				}
+			}
+
+			collectContainerImages(value, images)
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			collectContainerImages(child, images)
+		}
+	}
+}
+
+// ParseKubernetesManifests collects the container images of multi-document Kubernetes YAML,
+// including init and ephemeral containers.
+//
+// A false result indicates no Kubernetes objects, which declare apiVersion and kind.
+func ParseKubernetesManifests(content []byte) ([]Image, bool, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	var images []Image
+	var found bool
+
+	for {
+		var document yaml.Node
+
+		if err := decoder.Decode(&document); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, found, err
+		}
+
+		if len(document.Content) == 0 {
+			continue
+		}
+
+		object := document.Content[0]
+
+		if YAMLMappingValue(object, "apiVersion") == nil || YAMLMappingValue(object, "kind") == nil {
+			continue
+		}
+
+		found = true
+		collectContainerImages(object, &images)
+	}
+
+	return images, found, nil
+}
+
+// collectValuesImages gathers Helm values image references, at any depth:
+// repository mappings with optional registry, tag, and digest keys,
+// and plain image strings.
+//
+// appVersion substitutes for a missing tag in the repository mapping of node,
+// and of its image key, following Helm chart convention for the top-level image block.
+// Subchart and sidecar images lack such a default.
+func collectValuesImages(node *yaml.Node, appVersion string, images *[]Image) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		if repositoryNode := YAMLMappingValue(node, "repository"); repositoryNode != nil && repositoryNode.Kind == yaml.ScalarNode && repositoryNode.Value != "" {
+			reference := repositoryNode.Value
+
+			if registryNode := YAMLMappingValue(node, "registry"); registryNode != nil && registryNode.Value != "" {
+				reference = fmt.Sprintf("%v/%v", registryNode.Value, reference)
+			}
+
+			image := ParseImageReference(reference)
+			image.Line = repositoryNode.Line
+
+			if tagNode := YAMLMappingValue(node, "tag"); tagNode != nil && tagNode.Value != "" {
+				image.Tag = tagNode.Value
+			}
+
+			if digestNode := YAMLMappingValue(node, "digest"); digestNode != nil {
+				image.Digest = digestNode.Value
+			}
+
+			if image.Tag == "" {
+				image.Tag = appVersion
+			}
+
+			if image.Tag == "" && image.Digest == "" {
+				image.Tag = "latest"
+			}
+
+			*images = append(*images, image)
+			return
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+
+			if key.Value == "image" && value.Kind == yaml.ScalarNode && value.Value != "" {
+				image := ParseImageReference(value.Value)
+				image.Line = value.Line
+
+				if image.Tag == "" && image.Digest == "" {
+					image.Tag = "latest"
+				}
+
+				*images = append(*images, image)
+				continue
+			}
+
+			var childAppVersion string
+
+			if key.Value == "image" {
+				childAppVersion = appVersion
+			}
+
+			collectValuesImages(value, childAppVersion, images)
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			collectValuesImages(child, "", images)
+		}
+	}
+}
+
+// ParseHelmValues collects the image references of a Helm values file.
+//
+// appVersion denotes the chart appVersion, the default tag of the top-level image block.
+func ParseHelmValues(content []byte, appVersion string) ([]Image, error) {
+	var document yaml.Node
+
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+
+	var images []Image
+
+	for _, node := range document.Content {
+		collectValuesImages(node, appVersion, &images)
+	}
+
+	return images, nil
+}
+
+// ExtractKubernetesImages collects the container image references
+// of a Kubernetes manifest path, or of a Helm values file beside a Chart.yaml.
+//
+// A false result indicates a file other than a manifest or values file,
+// including unrendered Helm templates.
+// Malformed Helm charts and values files are logged and skipped.
+func ExtractKubernetesImages(pth string) ([]Image, bool, error) {
+	base := filepath.Base(pth)
+
+	if !KubernetesManifestPattern.MatchString(base) || ComposeFilePattern.MatchString(base) {
+		return nil, false, nil
+	}
+
+	content, err := os.ReadFile(pth)
+
+	if err != nil {
+		return nil, true, err
+	}
+
+	if HelmValuesPattern.MatchString(base) {
+		chartContent, err2 := os.ReadFile(filepath.Join(filepath.Dir(pth), HelmChartBase))
+
+		if err2 == nil {
+			var chart struct {
+				AppVersion string `yaml:"appVersion"`
+			}
+
+			if err3 := yaml.Unmarshal(chartContent, &chart); err3 != nil {
+				log.Printf("skipping helm values with malformed %v: %v: %v\n", HelmChartBase, pth, err3)
+				return nil, false, nil
+			}
+
+			images, err3 := ParseHelmValues(content, chart.AppVersion)
+
+			if err3 != nil {
+				log.Printf("skipping malformed helm values: %v: %v\n", pth, err3)
+				return nil, false, nil
+			}
+
+			return images, true, nil
+		}
+	}
+
+	images, found, err := ParseKubernetesManifests(content)
+
+	if err != nil || !found {
+		return nil, false, nil
+	}
+
+	return images, true, nil
+}
@@ -0,0 +1,129 @@
+package cicada_test
+
+import (
+	"github.com/mcandre/cicada"
+
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseKubernetesManifests(t *testing.T) {
+	content := []byte(`apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: migrate
+          image: flyway/flyway:7
+      containers:
+        - name: app
+          image: node:14-alpine3.15
+---
+apiVersion: batch/v1
+kind: CronJob
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: backup
+              image: postgres:11@sha256:0123
+---
+services:
+  db:
+    image: mysql:5.7
+`)
+
+	images, found, err := cicada.ParseKubernetesManifests(content)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []cicada.Image{
+		{Registry: "flyway", Name: "flyway", Tag: "7", Line: 8},
+		{Name: "node", Tag: "14-alpine3.15", Line: 11},
+		{Name: "postgres", Tag: "11", Digest: "sha256:0123", Line: 22},
+	}
+
+	if !found || len(images) != len(expected) {
+		t.Fatalf("Expected %v, got: %v", expected, images)
+	}
+
+	for i, image := range images {
+		if image != expected[i] {
+			t.Errorf("Expected %#v, got: %#v", expected[i], image)
+		}
+	}
+
+	if _, found, _ := cicada.ParseKubernetesManifests([]byte("services:\n  db:\n    image: mysql:5.7\n")); found {
+		t.Errorf("Expected non-Kubernetes YAML to be skipped")
+	}
+}
+
+func TestParseHelmValues(t *testing.T) {
+	content := []byte(`image:
+  registry: docker.io
+  repository: bitnami/redis
+  tag: ""
+metrics:
+  image:
+    repository: oliver006/redis_exporter
+    tag: 1.45.0
+sidecar:
+  image: busybox:1.36
+postgresql:
+  image:
+    repository: bitnami/postgresql
+`)
+
+	images, err := cicada.ParseHelmValues(content, "6.2.7")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []cicada.Image{
+		{Registry: "docker.io/bitnami", Name: "redis", Tag: "6.2.7", Line: 3},
+		{Registry: "oliver006", Name: "redis_exporter", Tag: "1.45.0", Line: 7},
+		{Name: "busybox", Tag: "1.36", Line: 10},
+		{Registry: "bitnami", Name: "postgresql", Tag: "latest", Line: 13},
+	}
+
+	if len(images) != len(expected) {
+		t.Fatalf("Expected %v, got: %v", expected, images)
+	}
+
+	for i, image := range images {
+		if image != expected[i] {
+			t.Errorf("Expected %#v, got: %#v", expected[i], image)
+		}
+	}
+}
+
+func TestExtractKubernetesImagesMalformedChart(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, cicada.HelmChartBase), []byte("appVersion: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pth := filepath.Join(dir, "values.yaml")
+
+	if err := os.WriteFile(pth, []byte("image:\n  repository: nginx\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	images, found, err := cicada.ExtractKubernetesImages(pth)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found || len(images) != 0 {
+		t.Errorf("Expected malformed chart to be skipped, got: %v", images)
+	}
+}
@@ -0,0 +1,177 @@
+package cicada
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"path/filepath"
+)
+
+// SourceKindEndOfLife identifies the endoflife.date service.
+const SourceKindEndOfLife = "endoflife"
+
+// SourceKindDirectory identifies a local directory of YAML schedules.
+const SourceKindDirectory = "directory"
+
+// SourceKindHTTP identifies a generic HTTP JSON endpoint.
+const SourceKindHTTP = "http"
+
+// IndexSourcesDirBase denotes the base path of the cached HTTP source documents,
+// relative to IndexCacheRoot.
+const IndexSourcesDirBase = "sources"
+
+// LifecycleSource models a provider of LTS schedules.
+type LifecycleSource interface {
+	// Products lists the component names known to the source.
+	Products() ([]string, error)
+
+	// Schedules fetches the support timelines of a component.
+	Schedules(product string) ([]Schedule, error)
+}
+
+// SourceConfig models a lifecycle data source declaration.
+type SourceConfig struct {
+	// Kind denotes the source implementation:
+	// "endoflife", "directory", or "http".
+	Kind string `json:"kind" toml:"kind" yaml:"kind"`
+
+	// Path denotes the schedules directory of a "directory" source,
+	// relative to the configuration.
+	Path string `json:"path,omitempty" toml:"path,omitempty" yaml:"path,omitempty"`
+
+	// URL denotes the JSON document location of an "http" source.
+	URL string `json:"url,omitempty" toml:"url,omitempty" yaml:"url,omitempty"`
+}
+
+// Validate ensures data integrity.
+func (o SourceConfig) Validate() error {
+	switch o.Kind {
+	case SourceKindEndOfLife:
+		return nil
+	case SourceKindDirectory:
+		if o.Path == "" {
+			return fmt.Errorf("directory source missing path")
+		}
+
+		return nil
+	case SourceKindHTTP:
+		if o.URL == "" {
+			return fmt.Errorf("http source missing url")
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown source kind: %v", o.Kind)
+	}
+}
+
+// NewLifecycleSource instantiates a source declaration.
+//
+// configDir anchors relative directory paths.
+//
+// policy controls caching of remote data.
+func (o SourceConfig) NewLifecycleSource(configDir string, policy CachePolicy) (LifecycleSource, error) {
+	switch o.Kind {
+	case SourceKindEndOfLife:
+		return NewEndOfLifeSource(policy)
+	case SourceKindDirectory:
+		pth := o.Path
+
+		if !filepath.IsAbs(pth) {
+			pth = filepath.Join(configDir, pth)
+		}
+
+		return &DirectorySource{Path: pth}, nil
+	case SourceKindHTTP:
+		digest := sha256.Sum256([]byte(o.URL))
+		cacheBase := fmt.Sprintf("%v.json", hex.EncodeToString(digest[:8]))
+
+		source := HTTPSource{
+			URL:       o.URL,
+			CachePath: path.Join(policy.Dir, IndexSourcesDirBase, cacheBase),
+			Policy:    policy,
+		}
+
+		return &source, nil
+	default:
+		return nil, fmt.Errorf("unknown source kind: %v", o.Kind)
+	}
+}
+
+// CompositeSource layers lifecycle sources.
+//
+// Earlier sources shadow later sources,
+// so that a private catalog may override public data
+// on a per-component basis.
+type CompositeSource struct {
+	// Sources denotes the layers, in descending priority.
+	Sources []LifecycleSource
+
+	// owners caches the highest priority source of each component.
+	owners map[string]LifecycleSource
+
+	// products caches the component names, in priority order.
+	products []string
+}
+
+// NewCompositeSource constructs a CompositeSource.
+func NewCompositeSource(sources ...LifecycleSource) *CompositeSource {
+	return &CompositeSource{Sources: sources}
+}
+
+// index resolves component ownership.
+func (o *CompositeSource) index() error {
+	if o.owners != nil {
+		return nil
+	}
+
+	owners := make(map[string]LifecycleSource)
+	var products []string
+
+	for _, source := range o.Sources {
+		sourceProducts, err := source.Products()
+
+		if err != nil {
+			return err
+		}
+
+		for _, product := range sourceProducts {
+			if _, ok := owners[product]; ok {
+				continue
+			}
+
+			owners[product] = source
+			products = append(products, product)
+		}
+	}
+
+	o.owners = owners
+	o.products = products
+	return nil
+}
+
+// Products lists the component names known to any layer.
+func (o *CompositeSource) Products() ([]string, error) {
+	if err := o.index(); err != nil {
+		return nil, err
+	}
+
+	return o.products, nil
+}
+
+// Schedules fetches the support timelines of a component
+// from the highest priority layer that knows the component.
+func (o *CompositeSource) Schedules(product string) ([]Schedule, error) {
+	if err := o.index(); err != nil {
+		return nil, err
+	}
+
+	source, ok := o.owners[product]
+
+	if !ok {
+		return nil, fmt.Errorf("unknown product: %v", product)
+	}
+
+	return source.Schedules(product)
+}
@@ -0,0 +1,61 @@
+package cicada_test
+
+import (
+	"github.com/mcandre/cicada"
+
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompositeSourceShadowing(t *testing.T) {
+	privateDir := t.TempDir()
+	publicDir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(privateDir, "widget.yaml"), []byte("- version: \"2.1\"\n  expiration: \"2030-01-31\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(publicDir, "widget.yaml"), []byte("- version: \"1.0\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(publicDir, "gadget.yml"), []byte("- version: \"3.0\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	source := cicada.NewCompositeSource(
+		cicada.DirectorySource{Path: privateDir},
+		cicada.DirectorySource{Path: publicDir},
+	)
+
+	products, err := source.Products()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(products) != 2 {
+		t.Errorf("Expected two products, got: %v", products)
+	}
+
+	schedules, err := source.Schedules("widget")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(schedules) != 1 || schedules[0].Version.Original() != "2.1" || schedules[0].Name != "widget" {
+		t.Errorf("Expected private widget schedule to shadow public schedule, got: %v", schedules)
+	}
+
+	schedules, err = source.Schedules("gadget")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(schedules) != 1 || schedules[0].Version.Original() != "3.0" {
+		t.Errorf("Expected public gadget schedule, got: %v", schedules)
+	}
+}
@@ -0,0 +1,537 @@
+package cicada
+
+import (
+	"github.com/BurntSushi/toml"
+
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// EcosystemPyPI identifies Python packages.
+const EcosystemPyPI = "pypi"
+
+// EcosystemRubyGems identifies Ruby gems.
+const EcosystemRubyGems = "rubygems"
+
+// EcosystemNPM identifies JavaScript packages.
+const EcosystemNPM = "npm"
+
+// EcosystemPackagist identifies PHP packages.
+const EcosystemPackagist = "packagist"
+
+// EcosystemMaven identifies Java artifacts, as groupId:artifactId.
+const EcosystemMaven = "maven"
+
+// DependencyProducts maps library dependencies to endoflife.date products,
+// keyed on ecosystem, then package name.
+var DependencyProducts = map[string]map[string]string{
+	EcosystemPyPI: {
+		"django": "django",
+	},
+	EcosystemRubyGems: {
+		"rails": "rails",
+	},
+	EcosystemNPM: {
+		"@angular/core": "angular",
+		"angular":       "angularjs",
+		"electron":      "electron",
+		"jquery":        "jquery",
+		"react":         "react",
+		"vue":           "vue",
+	},
+	EcosystemPackagist: {
+		"drupal/core":         "drupal",
+		"laravel/framework":   "laravel",
+		"symfony/http-kernel": "symfony",
+		"symfony/symfony":     "symfony",
+	},
+	EcosystemMaven: {
+		"log4j:log4j":                                         "log4j",
+		"org.apache.logging.log4j:log4j-core":                 "log4j",
+		"org.springframework.boot:spring-boot":                "spring-boot",
+		"org.springframework.boot:spring-boot-starter-parent": "spring-boot",
+		"org.springframework:spring-core":                     "spring-framework",
+	},
+}
+
+// Dependency models a resolved lockfile entry.
+type Dependency struct {
+	// Ecosystem denotes the package registry.
+	Ecosystem string
+
+	// Name denotes the package name.
+	Name string
+
+	// Version denotes the resolved version.
+	Version string
+
+	// Line denotes the one-based line number.
+	Line int
+}
+
+// Product maps the dependency to an endoflife.date product,
+// according to DependencyProducts.
+//
+// Blank indicates an unmapped dependency.
+func (o Dependency) Product() string {
+	return DependencyProducts[o.Ecosystem][o.Name]
+}
+
+// PyPINameSeparatorPattern matches runs of PEP 503 package name separators.
+var PyPINameSeparatorPattern = regexp.MustCompile(`[-_.]+`)
+
+// NormalizePyPIName applies PEP 503 package name normalization.
+func NormalizePyPIName(name string) string {
+	return strings.ToLower(PyPINameSeparatorPattern.ReplaceAllString(name, "-"))
+}
+
+// RequirementPinPattern extracts exact pins from pip requirements lines.
+var RequirementPinPattern = regexp.MustCompile(`^\s*(?P<Name>[A-Za-z0-9][A-Za-z0-9._-]*)\s*(\[[^\]]*\])?\s*===?\s*(?P<Version>[^\s;#,]+)`)
+
+// ParseRequirements extracts exact pins from pip requirements files.
+func ParseRequirements(content []byte) ([]Dependency, error) {
+	var dependencies []Dependency
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	var lineNumber int
+
+	for scanner.Scan() {
+		lineNumber++
+		match := RequirementPinPattern.FindStringSubmatch(scanner.Text())
+
+		if match == nil {
+			continue
+		}
+
+		dependencies = append(dependencies, Dependency{
+			Ecosystem: EcosystemPyPI,
+			Name:      NormalizePyPIName(match[RequirementPinPattern.SubexpIndex("Name")]),
+			Version:   match[RequirementPinPattern.SubexpIndex("Version")],
+			Line:      lineNumber,
+		})
+	}
+
+	return dependencies, scanner.Err()
+}
+
+// PoetryLockNamePattern locates package names in poetry.lock files.
+var PoetryLockNamePattern = regexp.MustCompile(`^name\s*=\s*"([^"]+)"`)
+
+// ParsePoetryLock extracts the packages of poetry.lock files.
+func ParsePoetryLock(content []byte) ([]Dependency, error) {
+	var lock struct {
+		Package []struct {
+			Name    string `toml:"name"`
+			Version string `toml:"version"`
+		} `toml:"package"`
+	}
+
+	if _, err := toml.Decode(string(content), &lock); err != nil {
+		return nil, err
+	}
+
+	var dependencies []Dependency
+	lines := lineIndex(content, PoetryLockNamePattern)
+
+	for _, pkg := range lock.Package {
+		dependencies = append(dependencies, Dependency{
+			Ecosystem: EcosystemPyPI,
+			Name:      NormalizePyPIName(pkg.Name),
+			Version:   pkg.Version,
+			Line:      lines[pkg.Name],
+		})
+	}
+
+	return dependencies, nil
+}
+
+// GemfileLockSpecPattern extracts top level gem specs from Gemfile.lock files.
+var GemfileLockSpecPattern = regexp.MustCompile(`^    (?P<Name>[^\s(]+) \((?P<Version>[^)]+)\)$`)
+
+// ParseGemfileLock extracts the gems of Gemfile.lock files.
+func ParseGemfileLock(content []byte) ([]Dependency, error) {
+	var dependencies []Dependency
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	var lineNumber int
+
+	for scanner.Scan() {
+		lineNumber++
+		match := GemfileLockSpecPattern.FindStringSubmatch(scanner.Text())
+
+		if match == nil {
+			continue
+		}
+
+		dependencies = append(dependencies, Dependency{
+			Ecosystem: EcosystemRubyGems,
+			Name:      match[GemfileLockSpecPattern.SubexpIndex("Name")],
+			Version:   match[GemfileLockSpecPattern.SubexpIndex("Version")],
+			Line:      lineNumber,
+		})
+	}
+
+	return dependencies, scanner.Err()
+}
+
+// npmLockEntry models package-lock.json entries.
+type npmLockEntry struct {
+	Version      string                  `json:"version"`
+	Dependencies map[string]npmLockEntry `json:"dependencies"`
+}
+
+// PackageLockKeyPattern locates object keys in package-lock.json files.
+var PackageLockKeyPattern = regexp.MustCompile(`^\s*"([^"]+)"\s*:`)
+
+// PackageLockObjectPattern locates object valued keys in package-lock.json files.
+var PackageLockObjectPattern = regexp.MustCompile(`^\s*"([^"]+)"\s*:\s*\{`)
+
+// ParsePackageLock extracts the packages of package-lock.json files,
+// in lockfile versions 1 through 3.
+func ParsePackageLock(content []byte) ([]Dependency, error) {
+	var lock struct {
+		Packages     map[string]npmLockEntry `json:"packages"`
+		Dependencies map[string]npmLockEntry `json:"dependencies"`
+	}
+
+	if err := json.Unmarshal(content, &lock); err != nil {
+		return nil, err
+	}
+
+	var dependencies []Dependency
+
+	if len(lock.Packages) != 0 {
+		lines := lineIndex(content, PackageLockKeyPattern)
+
+		for key, entry := range lock.Packages {
+			i := strings.LastIndex(key, "node_modules/")
+
+			if i < 0 || entry.Version == "" {
+				continue
+			}
+
+			dependencies = append(dependencies, Dependency{
+				Ecosystem: EcosystemNPM,
+				Name:      key[i+len("node_modules/"):],
+				Version:   entry.Version,
+				Line:      lines[key],
+			})
+		}
+	} else {
+		lines := lineIndex(content, PackageLockObjectPattern)
+		var walk func(entries map[string]npmLockEntry)
+
+		walk = func(entries map[string]npmLockEntry) {
+			for name, entry := range entries {
+				dependencies = append(dependencies, Dependency{
+					Ecosystem: EcosystemNPM,
+					Name:      name,
+					Version:   entry.Version,
+					Line:      lines[name],
+				})
+
+				walk(entry.Dependencies)
+			}
+		}
+
+		walk(lock.Dependencies)
+	}
+
+	sort.Slice(dependencies, func(i, j int) bool {
+		return dependencies[i].Line < dependencies[j].Line
+	})
+
+	return dependencies, nil
+}
+
+// YarnLockVersionPattern extracts resolved versions from yarn.lock entries.
+var YarnLockVersionPattern = regexp.MustCompile(`^\s+version:?\s+"?(?P<Version>[^"\s]+)"?$`)
+
+// yarnSpecName extracts the package name from yarn.lock specifiers,
+// such as react@^18.2.0 or "@angular/core@npm:^15.0.0".
+func yarnSpecName(spec string) string {
+	spec = strings.Trim(strings.TrimSpace(spec), `"`)
+	i := strings.LastIndex(spec, "@")
+
+	if i <= 0 {
+		return spec
+	}
+
+	return spec[:i]
+}
+
+// ParseYarnLock extracts the packages of yarn.lock files,
+// in classic and berry formats.
+func ParseYarnLock(content []byte) ([]Dependency, error) {
+	var dependencies []Dependency
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	var lineNumber int
+	var name string
+	var nameLine int
+
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if !strings.HasPrefix(line, " ") && strings.HasSuffix(line, ":") {
+			specs := strings.Split(strings.TrimSuffix(line, ":"), ",")
+			name = yarnSpecName(specs[0])
+			nameLine = lineNumber
+
+			if name == "__metadata" {
+				name = ""
+			}
+
+			continue
+		}
+
+		match := YarnLockVersionPattern.FindStringSubmatch(line)
+
+		if match == nil || name == "" {
+			continue
+		}
+
+		dependencies = append(dependencies, Dependency{
+			Ecosystem: EcosystemNPM,
+			Name:      name,
+			Version:   match[YarnLockVersionPattern.SubexpIndex("Version")],
+			Line:      nameLine,
+		})
+
+		name = ""
+	}
+
+	return dependencies, scanner.Err()
+}
+
+// PnpmLockPackagePattern extracts package keys from pnpm-lock.yaml files,
+// across /name/version, /name@version, and name@version formats.
+var PnpmLockPackagePattern = regexp.MustCompile(`^  '?/?(?P<Name>(@[^/@]+/)?[^/@'(\s]+)[@/](?P<Version>[0-9][^('\s:]*)`)
+
+// ParsePnpmLock extracts the packages of pnpm-lock.yaml files.
+func ParsePnpmLock(content []byte) ([]Dependency, error) {
+	var dependencies []Dependency
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	var lineNumber int
+	var inPackages bool
+
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+
+		if !strings.HasPrefix(line, " ") && line != "" {
+			inPackages = line == "packages:"
+			continue
+		}
+
+		if !inPackages {
+			continue
+		}
+
+		match := PnpmLockPackagePattern.FindStringSubmatch(line)
+
+		if match == nil {
+			continue
+		}
+
+		dependencies = append(dependencies, Dependency{
+			Ecosystem: EcosystemNPM,
+			Name:      match[PnpmLockPackagePattern.SubexpIndex("Name")],
+			Version:   match[PnpmLockPackagePattern.SubexpIndex("Version")],
+			Line:      lineNumber,
+		})
+	}
+
+	return dependencies, scanner.Err()
+}
+
+// ComposerLockNamePattern locates package names in composer.lock files.
+var ComposerLockNamePattern = regexp.MustCompile(`"name"\s*:\s*"([^"]+)"`)
+
+// ParseComposerLock extracts the packages of composer.lock files.
+func ParseComposerLock(content []byte) ([]Dependency, error) {
+	type composerPackage struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+
+	var lock struct {
+		Packages    []composerPackage `json:"packages"`
+		PackagesDev []composerPackage `json:"packages-dev"`
+	}
+
+	if err := json.Unmarshal(content, &lock); err != nil {
+		return nil, err
+	}
+
+	var dependencies []Dependency
+	lines := lineIndex(content, ComposerLockNamePattern)
+
+	for _, pkg := range append(lock.Packages, lock.PackagesDev...) {
+		dependencies = append(dependencies, Dependency{
+			Ecosystem: EcosystemPackagist,
+			Name:      pkg.Name,
+			Version:   pkg.Version,
+			Line:      lines[pkg.Name],
+		})
+	}
+
+	return dependencies, nil
+}
+
+// MavenPropertyPattern matches Maven property references.
+var MavenPropertyPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// POMArtifactIDPattern locates artifact IDs in pom.xml files.
+var POMArtifactIDPattern = regexp.MustCompile(`<artifactId>\s*([^<\s]+)\s*</artifactId>`)
+
+// ParsePOM extracts the parent and explicitly versioned dependencies of pom.xml files.
+//
+// Simple ${property} references resolve against the POM properties.
+func ParsePOM(content []byte) ([]Dependency, error) {
+	type artifact struct {
+		GroupID    string `xml:"groupId"`
+		ArtifactID string `xml:"artifactId"`
+		Version    string `xml:"version"`
+	}
+
+	var pom struct {
+		Parent     artifact `xml:"parent"`
+		Properties struct {
+			Entries []struct {
+				XMLName xml.Name
+				Value   string `xml:",chardata"`
+			} `xml:",any"`
+		} `xml:"properties"`
+		Dependencies        []artifact `xml:"dependencies>dependency"`
+		ManagedDependencies []artifact `xml:"dependencyManagement>dependencies>dependency"`
+	}
+
+	if err := xml.Unmarshal(content, &pom); err != nil {
+		return nil, err
+	}
+
+	properties := make(map[string]string)
+
+	for _, entry := range pom.Properties.Entries {
+		properties[entry.XMLName.Local] = strings.TrimSpace(entry.Value)
+	}
+
+	var dependencies []Dependency
+	lines := lineIndex(content, POMArtifactIDPattern)
+
+	for _, a := range append([]artifact{pom.Parent}, append(pom.Dependencies, pom.ManagedDependencies...)...) {
+		version := MavenPropertyPattern.ReplaceAllStringFunc(strings.TrimSpace(a.Version), func(reference string) string {
+			return properties[MavenPropertyPattern.FindStringSubmatch(reference)[1]]
+		})
+
+		if a.ArtifactID == "" || version == "" {
+			continue
+		}
+
+		dependencies = append(dependencies, Dependency{
+			Ecosystem: EcosystemMaven,
+			Name:      fmt.Sprintf("%v:%v", strings.TrimSpace(a.GroupID), strings.TrimSpace(a.ArtifactID)),
+			Version:   version,
+			Line:      lines[strings.TrimSpace(a.ArtifactID)],
+		})
+	}
+
+	return dependencies, nil
+}
+
+// ParseGradleLockfile extracts the artifacts of gradle.lockfile files.
+func ParseGradleLockfile(content []byte) ([]Dependency, error) {
+	var dependencies []Dependency
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	var lineNumber int
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		coordinates, _, _ := strings.Cut(line, "=")
+		parts := strings.Split(coordinates, ":")
+
+		if len(parts) != 3 {
+			continue
+		}
+
+		dependencies = append(dependencies, Dependency{
+			Ecosystem: EcosystemMaven,
+			Name:      fmt.Sprintf("%v:%v", parts[0], parts[1]),
+			Version:   parts[2],
+			Line:      lineNumber,
+		})
+	}
+
+	return dependencies, scanner.Err()
+}
+
+// LockfileParsers maps lockfile base names to dependency parsers.
+var LockfileParsers = map[string]func([]byte) ([]Dependency, error){
+	"Gemfile.lock":      ParseGemfileLock,
+	"composer.lock":     ParseComposerLock,
+	"gradle.lockfile":   ParseGradleLockfile,
+	"package-lock.json": ParsePackageLock,
+	"pnpm-lock.yaml":    ParsePnpmLock,
+	"poetry.lock":       ParsePoetryLock,
+	"pom.xml":           ParsePOM,
+	"yarn.lock":         ParseYarnLock,
+}
+
+// RequirementsPattern matches pip requirements files.
+var RequirementsPattern = regexp.MustCompile(`^requirements.*\.txt$`)
+
+// ParseLockfile decodes the library dependencies of any supported lockfile,
+// as pins of the mapped products.
+//
+// A false result indicates an unsupported file.
+func ParseLockfile(pth string) ([]ToolPin, bool, error) {
+	base := filepath.Base(pth)
+	parse, ok := LockfileParsers[base]
+
+	if !ok && RequirementsPattern.MatchString(base) {
+		parse, ok = ParseRequirements, true
+	}
+
+	if !ok {
+		return nil, false, nil
+	}
+
+	content, err := os.ReadFile(pth)
+
+	if err != nil {
+		return nil, true, err
+	}
+
+	dependencies, err := parse(content)
+
+	if err != nil {
+		return nil, true, fmt.Errorf("%v: %v", pth, err)
+	}
+
+	var pins []ToolPin
+
+	for _, dependency := range dependencies {
+		if product := dependency.Product(); product != "" {
+			pins = append(pins, ToolPin{Tool: product, Version: dependency.Version, Line: dependency.Line})
+		}
+	}
+
+	return pins, true, nil
+}
@@ -0,0 +1,234 @@
+package cicada_test
+
+import (
+	"github.com/mcandre/cicada"
+
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRequirements(t *testing.T) {
+	dependencies, err := cicada.ParseRequirements([]byte("# web\nDjango[argon2]==3.2.18 ; python_version >= \"3.8\"\nrequests>=2\n"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dependencies) != 1 || dependencies[0].Product() != "django" || dependencies[0].Version != "3.2.18" || dependencies[0].Line != 2 {
+		t.Errorf("Expected django 3.2.18 on line 2, got: %v", dependencies)
+	}
+}
+
+func TestParseYarnLock(t *testing.T) {
+	classic := []byte(`# yarn lockfile v1
+
+"@angular/core@^15.0.0", "@angular/core@~15.2.0":
+  version "15.2.9"
+  resolved "https://registry.yarnpkg.com/@angular/core/-/core-15.2.9.tgz"
+`)
+
+	berry := []byte(`__metadata:
+  version: 6
+
+"react@npm:^17.0.2":
+  version: 17.0.2
+`)
+
+	for content, expected := range map[string]cicada.Dependency{
+		string(classic): {Ecosystem: cicada.EcosystemNPM, Name: "@angular/core", Version: "15.2.9", Line: 3},
+		string(berry):   {Ecosystem: cicada.EcosystemNPM, Name: "react", Version: "17.0.2", Line: 4},
+	} {
+		dependencies, err := cicada.ParseYarnLock([]byte(content))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(dependencies) != 1 || dependencies[0] != expected {
+			t.Errorf("Expected %v, got: %v", expected, dependencies)
+		}
+	}
+}
+
+func TestParsePnpmLock(t *testing.T) {
+	content := []byte(`lockfileVersion: '6.0'
+
+dependencies:
+  vue:
+    specifier: ^2.7.0
+    version: 2.7.14
+
+packages:
+
+  /@babel/parser@7.22.5:
+    resolution: {integrity: sha512-x}
+
+  /vue@2.7.14:
+    resolution: {integrity: sha512-y}
+`)
+
+	dependencies, err := cicada.ParsePnpmLock(content)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dependencies) != 2 || dependencies[1].Product() != "vue" || dependencies[1].Version != "2.7.14" || dependencies[1].Line != 13 {
+		t.Errorf("Expected vue 2.7.14 on line 13, got: %v", dependencies)
+	}
+}
+
+func TestParsePackageLock(t *testing.T) {
+	v3 := []byte(`{
+  "name": "app",
+  "lockfileVersion": 3,
+  "packages": {
+    "": {
+      "name": "app"
+    },
+    "node_modules/lodash": {
+      "version": "4.17.21"
+    },
+    "node_modules/react": {
+      "version": "17.0.2"
+    }
+  }
+}
+`)
+
+	v1 := []byte(`{
+  "name": "app",
+  "lockfileVersion": 1,
+  "dependencies": {
+    "lodash": {
+      "version": "4.17.21"
+    },
+    "react": {
+      "version": "16.14.0"
+    }
+  }
+}
+`)
+
+	for content, expected := range map[string]cicada.Dependency{
+		string(v3): {Ecosystem: cicada.EcosystemNPM, Name: "react", Version: "17.0.2", Line: 11},
+		string(v1): {Ecosystem: cicada.EcosystemNPM, Name: "react", Version: "16.14.0", Line: 8},
+	} {
+		dependencies, err := cicada.ParsePackageLock([]byte(content))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(dependencies) != 2 || dependencies[1] != expected {
+			t.Errorf("Expected %v, got: %v", expected, dependencies)
+		}
+	}
+}
+
+func TestParseComposerLock(t *testing.T) {
+	content := []byte(`{
+    "packages": [
+        {
+            "name": "monolog/monolog",
+            "version": "2.9.1"
+        },
+        {
+            "name": "laravel/framework",
+            "version": "v9.52.16"
+        }
+    ]
+}
+`)
+
+	dependencies, err := cicada.ParseComposerLock(content)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dependencies) != 2 || dependencies[1].Product() != "laravel" || dependencies[1].Line != 8 {
+		t.Errorf("Expected laravel on line 8, got: %v", dependencies)
+	}
+}
+
+func TestParsePoetryLock(t *testing.T) {
+	content := []byte(`[[package]]
+name = "requests"
+version = "2.31.0"
+
+[[package]]
+name = "Django"
+version = "3.2.18"
+`)
+
+	dependencies, err := cicada.ParsePoetryLock(content)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dependencies) != 2 || dependencies[1].Product() != "django" || dependencies[1].Name != "django" || dependencies[1].Line != 6 {
+		t.Errorf("Expected django on line 6, got: %v", dependencies)
+	}
+}
+
+func TestParsePOM(t *testing.T) {
+	content := []byte(`<project>
+  <parent>
+    <groupId>org.springframework.boot</groupId>
+    <artifactId>spring-boot-starter-parent</artifactId>
+    <version>2.5.4</version>
+  </parent>
+  <properties>
+    <log4j.version>2.14.1</log4j.version>
+  </properties>
+  <dependencies>
+    <dependency>
+      <groupId>org.apache.logging.log4j</groupId>
+      <artifactId>log4j-core</artifactId>
+      <version>${log4j.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>junit</groupId>
+      <artifactId>junit</artifactId>
+    </dependency>
+  </dependencies>
+</project>
+`)
+
+	dependencies, err := cicada.ParsePOM(content)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dependencies) != 2 || dependencies[0].Product() != "spring-boot" || dependencies[0].Line != 4 || dependencies[1].Product() != "log4j" || dependencies[1].Version != "2.14.1" || dependencies[1].Line != 13 {
+		t.Errorf("Expected spring-boot 2.5.4 and log4j 2.14.1, got: %v", dependencies)
+	}
+}
+
+func TestScanLockfiles(t *testing.T) {
+	index := loadProject(t, map[string]string{
+		"lc/react.yaml":         "- version: \"17\"\n  expiration: \"2022-03-29\"\n",
+		"lc/log4j.yaml":         "- version: \"2\"\n  expiration: \"2099-01-01\"\n- version: \"1\"\n  expiration: \"2015-08-05\"\n",
+		"web/package-lock.json": "{\n  \"lockfileVersion\": 3,\n  \"packages\": {\n    \"node_modules/react\": {\n      \"version\": \"17.0.2\"\n    }\n  }\n}\n",
+		"api/gradle.lockfile":   "org.apache.logging.log4j:log4j-core:2.17.1=runtimeClasspath\nlog4j:log4j:1.2.17=runtimeClasspath\n",
+	})
+
+	warnings, err := index.ScanLockfiles(time.Now())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"end of life for log4j 1.2.17 on 2015-08-05 at api/gradle.lockfile:2",
+		"end of life for react 17.0.2 on 2022-03-29 at web/package-lock.json:4",
+	}
+
+	if !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("Expected %v, got: %v", expected, warnings)
+	}
+}
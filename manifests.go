@@ -0,0 +1,362 @@
+package cicada
+
+import (
+	"github.com/BurntSushi/toml"
+	"github.com/Masterminds/semver"
+
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ConstraintClausePattern splits version constraints into operator and version clauses.
+var ConstraintClausePattern = regexp.MustCompile(`(?P<Operator><=|>=|!=|~=|===|==|\^|~>|~|<|>|=)?\s*v?(?P<Version>[0-9]+(\.[0-9x*]+){0,2})`)
+
+// LowestVersion identifies the lowest version allowed by a version constraint,
+// such as >=3.8,<4 or ^14.17 || >=16.
+//
+// Upper bounds and exclusions are ignored.
+// Wildcard elements are dropped.
+//
+// Blank indicates no lower bound.
+func LowestVersion(constraint string) string {
+	var lowest *semver.Version
+	var lowestString string
+	operatorIndex := ConstraintClausePattern.SubexpIndex("Operator")
+	versionIndex := ConstraintClausePattern.SubexpIndex("Version")
+
+	for _, match := range ConstraintClausePattern.FindAllStringSubmatch(constraint, -1) {
+		switch match[operatorIndex] {
+		case "<", "<=", "!=":
+			continue
+		}
+
+		var elements []string
+
+		for _, element := range strings.Split(match[versionIndex], ".") {
+			if element == "x" || element == "*" {
+				break
+			}
+
+			elements = append(elements, element)
+		}
+
+		if len(elements) == 0 {
+			continue
+		}
+
+		versionString := strings.Join(elements, ".")
+		version, err := semver.NewVersion(versionString)
+
+		if err != nil {
+			continue
+		}
+
+		if lowest == nil || version.LessThan(lowest) {
+			lowest = version
+			lowestString = versionString
+		}
+	}
+
+	return lowestString
+}
+
+// lineIndex maps the first capture group of a pattern
+// to the line number of its first matching line, in a single pass.
+func lineIndex(content []byte, pattern *regexp.Regexp) map[string]int {
+	index := make(map[string]int)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	var lineNumber int
+
+	for scanner.Scan() {
+		lineNumber++
+
+		for _, match := range pattern.FindAllStringSubmatch(scanner.Text(), -1) {
+			if _, ok := index[match[1]]; !ok {
+				index[match[1]] = lineNumber
+			}
+		}
+	}
+
+	return index
+}
+
+// lineOf locates the first line matching a pattern, at or after a starting line.
+//
+// Zero indicates no match.
+func lineOf(content []byte, pattern *regexp.Regexp, start int) int {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	var lineNumber int
+
+	for scanner.Scan() {
+		lineNumber++
+
+		if lineNumber >= start && pattern.MatchString(scanner.Text()) {
+			return lineNumber
+		}
+	}
+
+	return 0
+}
+
+// GoModDirectivePattern extracts go and toolchain directives.
+var GoModDirectivePattern = regexp.MustCompile(`^\s*(?P<Directive>go|toolchain)\s+(go)?(?P<Version>[0-9][^\s/]*)`)
+
+// ParseGoMod extracts the go and toolchain directives of go.mod files.
+func ParseGoMod(content []byte) ([]ToolPin, error) {
+	var pins []ToolPin
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	var lineNumber int
+
+	for scanner.Scan() {
+		lineNumber++
+		match := GoModDirectivePattern.FindStringSubmatch(scanner.Text())
+
+		if match == nil {
+			continue
+		}
+
+		pins = append(pins, ToolPin{Tool: "go", Version: match[GoModDirectivePattern.SubexpIndex("Version")], Line: lineNumber})
+	}
+
+	return pins, scanner.Err()
+}
+
+// ParsePackageJSON extracts engines.node from package.json files.
+func ParsePackageJSON(content []byte) ([]ToolPin, error) {
+	var manifest struct {
+		Engines map[string]interface{} `json:"engines"`
+	}
+
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, err
+	}
+
+	constraint, ok := manifest.Engines["node"].(string)
+
+	if !ok {
+		return nil, nil
+	}
+
+	version := LowestVersion(constraint)
+
+	if version == "" {
+		return nil, nil
+	}
+
+	line := lineOf(content, regexp.MustCompile(`"node"\s*:`), lineOf(content, regexp.MustCompile(`"engines"\s*:`), 1))
+	return []ToolPin{{Tool: "nodejs", Version: version, Line: line}}, nil
+}
+
+// ParseComposerJSON extracts require.php from composer.json files.
+func ParseComposerJSON(content []byte) ([]ToolPin, error) {
+	var manifest struct {
+		Require map[string]interface{} `json:"require"`
+	}
+
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, err
+	}
+
+	constraint, ok := manifest.Require["php"].(string)
+
+	if !ok {
+		return nil, nil
+	}
+
+	version := LowestVersion(constraint)
+
+	if version == "" {
+		return nil, nil
+	}
+
+	line := lineOf(content, regexp.MustCompile(`"php"\s*:`), lineOf(content, regexp.MustCompile(`"require"\s*:`), 1))
+	return []ToolPin{{Tool: "php", Version: version, Line: line}}, nil
+}
+
+// parseTOMLKey extracts a string key from a TOML table,
+// reporting the lowest allowed version.
+func parseTOMLKey(content []byte, table string, key string, tool string) ([]ToolPin, error) {
+	var document map[string]interface{}
+
+	if _, err := toml.Decode(string(content), &document); err != nil {
+		return nil, err
+	}
+
+	var value interface{} = document
+
+	for _, element := range strings.Split(table, ".") {
+		m, ok := value.(map[string]interface{})
+
+		if !ok {
+			return nil, nil
+		}
+
+		value = m[element]
+	}
+
+	m, ok := value.(map[string]interface{})
+
+	if !ok {
+		return nil, nil
+	}
+
+	constraint, ok := m[key].(string)
+
+	if !ok {
+		return nil, nil
+	}
+
+	version := LowestVersion(constraint)
+
+	if version == "" {
+		return nil, nil
+	}
+
+	tableLine := lineOf(content, regexp.MustCompile(fmt.Sprintf(`^\s*\[%v\]`, regexp.QuoteMeta(table))), 1)
+	line := lineOf(content, regexp.MustCompile(fmt.Sprintf(`^\s*"?%v"?\s*=`, regexp.QuoteMeta(key))), tableLine)
+	return []ToolPin{{Tool: tool, Version: version, Line: line}}, nil
+}
+
+// ParsePyProject extracts the supported Python versions of pyproject.toml files,
+// from PEP 621 requires-python, or else Poetry dependencies.
+func ParsePyProject(content []byte) ([]ToolPin, error) {
+	pins, err := parseTOMLKey(content, "project", "requires-python", "python")
+
+	if err != nil || len(pins) != 0 {
+		return pins, err
+	}
+
+	return parseTOMLKey(content, "tool.poetry.dependencies", "python", "python")
+}
+
+// ParseCargoTOML extracts rust-version from Cargo.toml files.
+func ParseCargoTOML(content []byte) ([]ToolPin, error) {
+	return parseTOMLKey(content, "package", "rust-version", "rust")
+}
+
+// GemfileRubyPattern extracts ruby directives from Gemfiles.
+var GemfileRubyPattern = regexp.MustCompile(`^\s*ruby\s*\(?\s*["'](?P<Constraint>[^"']+)["']`)
+
+// ParseGemfile extracts the ruby directive of Gemfiles.
+func ParseGemfile(content []byte) ([]ToolPin, error) {
+	var pins []ToolPin
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	var lineNumber int
+
+	for scanner.Scan() {
+		lineNumber++
+		match := GemfileRubyPattern.FindStringSubmatch(scanner.Text())
+
+		if match == nil {
+			continue
+		}
+
+		version := LowestVersion(match[GemfileRubyPattern.SubexpIndex("Constraint")])
+
+		if version != "" {
+			pins = append(pins, ToolPin{Tool: "ruby", Version: version, Line: lineNumber})
+		}
+	}
+
+	return pins, scanner.Err()
+}
+
+// TargetFrameworkPattern extracts target framework monikers from .NET project files.
+var TargetFrameworkPattern = regexp.MustCompile(`<TargetFrameworks?>\s*(?P<Frameworks>[^<]+?)\s*</TargetFrameworks?>`)
+
+// TargetFrameworkMonikerPattern decomposes .NET target framework monikers.
+var TargetFrameworkMonikerPattern = regexp.MustCompile(`^(?P<Family>netcoreapp|net)(?P<Version>[0-9]+(\.[0-9]+)?)(-.+)?$`)
+
+// TargetFrameworkPin maps a .NET target framework moniker to a pin.
+//
+// For example, net6.0 yields dotnet 6.0, and net48 yields dotnetfx 4.8.
+// .NET Standard monikers denote no runtime.
+//
+// A false result indicates an unsupported moniker.
+func TargetFrameworkPin(moniker string, line int) (*ToolPin, bool) {
+	match := TargetFrameworkMonikerPattern.FindStringSubmatch(strings.ToLower(moniker))
+
+	if match == nil {
+		return nil, false
+	}
+
+	version := match[TargetFrameworkMonikerPattern.SubexpIndex("Version")]
+
+	if !strings.Contains(version, ".") {
+		// Legacy .NET Framework monikers omit dots, as in net472.
+		version = strings.Join(strings.Split(version, ""), ".")
+		return &ToolPin{Tool: "dotnetfx", Version: version, Line: line}, true
+	}
+
+	return &ToolPin{Tool: "dotnet", Version: version, Line: line}, true
+}
+
+// ParseCSProj extracts the target frameworks of .csproj files.
+func ParseCSProj(content []byte) ([]ToolPin, error) {
+	var pins []ToolPin
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	var lineNumber int
+
+	for scanner.Scan() {
+		lineNumber++
+		match := TargetFrameworkPattern.FindStringSubmatch(scanner.Text())
+
+		if match == nil {
+			continue
+		}
+
+		for _, moniker := range strings.Split(match[TargetFrameworkPattern.SubexpIndex("Frameworks")], ";") {
+			if pin, ok := TargetFrameworkPin(strings.TrimSpace(moniker), lineNumber); ok {
+				pins = append(pins, *pin)
+			}
+		}
+	}
+
+	return pins, scanner.Err()
+}
+
+// ManifestParsers maps manifest base names to runtime declaration parsers.
+var ManifestParsers = map[string]func([]byte) ([]ToolPin, error){
+	"Cargo.toml":     ParseCargoTOML,
+	"Gemfile":        ParseGemfile,
+	"composer.json":  ParseComposerJSON,
+	"go.mod":         ParseGoMod,
+	"package.json":   ParsePackageJSON,
+	"pyproject.toml": ParsePyProject,
+}
+
+// ParseManifest decodes the runtime declarations of any supported language manifest.
+//
+// A false result indicates an unsupported file.
+func ParseManifest(pth string) ([]ToolPin, bool, error) {
+	base := filepath.Base(pth)
+	parse, ok := ManifestParsers[base]
+
+	if !ok && strings.HasSuffix(base, ".csproj") {
+		parse, ok = ParseCSProj, true
+	}
+
+	if !ok {
+		return nil, false, nil
+	}
+
+	content, err := os.ReadFile(pth)
+
+	if err != nil {
+		return nil, true, err
+	}
+
+	pins, err := parse(content)
+
+	if err != nil {
+		return nil, true, fmt.Errorf("%v: %v", pth, err)
+	}
+
+	return pins, true, nil
+}
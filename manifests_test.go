@@ -0,0 +1,79 @@
+package cicada_test
+
+import (
+	"github.com/mcandre/cicada"
+
+	"testing"
+)
+
+func TestLowestVersion(t *testing.T) {
+	for constraint, expected := range map[string]string{
+		">=3.8,<4":         "3.8",
+		"^14.17 || >=16":   "14.17",
+		"~> 3.0":           "3.0",
+		"3.8.*":            "3.8",
+		">=7.4 <9 || ^8.0": "7.4",
+		"v18.17.0":         "18.17.0",
+		"<4":               "",
+		"*":                "",
+	} {
+		if actual := cicada.LowestVersion(constraint); actual != expected {
+			t.Errorf("Expected lowest version of %q to be %q, got: %q", constraint, expected, actual)
+		}
+	}
+}
+
+func TestParsePackageJSON(t *testing.T) {
+	content := []byte(`{
+  "name": "widget",
+  "dependencies": {
+    "node": "^1.0.0"
+  },
+  "engines": {
+    "npm": ">=8",
+    "node": ">=14 <21"
+  }
+}
+`)
+
+	pins, err := cicada.ParsePackageJSON(content)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pins) != 1 || pins[0].Product() != "nodejs" || pins[0].Version != "14" || pins[0].Line != 8 {
+		t.Errorf("Expected nodejs 14 on line 8, got: %v", pins)
+	}
+}
+
+func TestParseGoMod(t *testing.T) {
+	pins, err := cicada.ParseGoMod([]byte("module example.com/widget\n\ngo 1.20\n\ntoolchain go1.21.5\n"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pins) != 2 || pins[0].Version != "1.20" || pins[1].Version != "1.21.5" || pins[1].Line != 5 {
+		t.Errorf("Expected go 1.20 and toolchain 1.21.5, got: %v", pins)
+	}
+}
+
+func TestParseCSProj(t *testing.T) {
+	content := []byte(`<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFrameworks>net472;netstandard2.0;net6.0-windows</TargetFrameworks>
+  </PropertyGroup>
+</Project>
+`)
+
+	pins, err := cicada.ParseCSProj(content)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pins) != 2 || pins[0].Product() != "dotnetfx" || pins[0].Version != "4.7.2" || pins[1].Product() != "dotnet" || pins[1].Version != "6.0" {
+		t.Errorf("Expected dotnetfx 4.7.2 and dotnet 6.0, got: %v", pins)
+	}
+}
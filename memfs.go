@@ -0,0 +1,389 @@
+package cicada
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxSymlinkHops bounds symlink resolution.
+const MaxSymlinkHops = 40
+
+// memNode models an in-memory file, directory, or symlink.
+type memNode struct {
+	// mode denotes the file type and permissions.
+	mode fs.FileMode
+
+	// data denotes regular file content.
+	//
+	// nil indicates content omitted to conserve memory.
+	data []byte
+
+	// size denotes the regular file size.
+	size int64
+
+	// target denotes the symlink destination.
+	target string
+
+	// modTime denotes the modification timestamp.
+	modTime time.Time
+}
+
+// MemFS models a read-only in-memory filesystem,
+// such as a flattened container image.
+//
+// Symlinks resolve within the filesystem,
+// with absolute targets relative to the root.
+type MemFS struct {
+	// nodes denotes the entries, keyed on clean slash-separated path.
+	nodes map[string]*memNode
+}
+
+// NewMemFS constructs an empty MemFS.
+func NewMemFS() *MemFS {
+	return &MemFS{nodes: map[string]*memNode{".": {mode: fs.ModeDir | 0755}}}
+}
+
+// cleanName normalizes paths relative to the root.
+func cleanName(name string) string {
+	name = path.Clean("/" + name)
+
+	if name == "/" {
+		return "."
+	}
+
+	return strings.TrimPrefix(name, "/")
+}
+
+// ensureParents creates any missing ancestor directories.
+func (o *MemFS) ensureParents(name string) {
+	for dir := path.Dir(name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if _, ok := o.nodes[dir]; ok {
+			return
+		}
+
+		o.nodes[dir] = &memNode{mode: fs.ModeDir | 0755}
+	}
+}
+
+// AddDir records a directory.
+func (o *MemFS) AddDir(name string, mode fs.FileMode, modTime time.Time) {
+	name = cleanName(name)
+	o.ensureParents(name)
+	o.nodes[name] = &memNode{mode: fs.ModeDir | mode.Perm(), modTime: modTime}
+}
+
+// AddFile records a regular file.
+//
+// nil data with a positive size indicates omitted content.
+func (o *MemFS) AddFile(name string, mode fs.FileMode, data []byte, size int64, modTime time.Time) {
+	name = cleanName(name)
+	o.ensureParents(name)
+	o.nodes[name] = &memNode{mode: mode.Perm(), data: data, size: size, modTime: modTime}
+}
+
+// AddSymlink records a symlink.
+func (o *MemFS) AddSymlink(name string, target string, modTime time.Time) {
+	name = cleanName(name)
+	o.ensureParents(name)
+	o.nodes[name] = &memNode{mode: fs.ModeSymlink | 0777, target: target, modTime: modTime}
+}
+
+// AddLink records a hard link to an existing regular file.
+func (o *MemFS) AddLink(name string, target string) error {
+	node, ok := o.nodes[cleanName(target)]
+
+	if !ok {
+		return fmt.Errorf("hard link target not found: %v -> %v", name, target)
+	}
+
+	name = cleanName(name)
+	o.ensureParents(name)
+	linked := *node
+	o.nodes[name] = &linked
+	return nil
+}
+
+// Remove deletes an entry, along with any descendants.
+func (o *MemFS) Remove(name string) {
+	name = cleanName(name)
+	prefix := name + "/"
+
+	for key := range o.nodes {
+		if key == name || strings.HasPrefix(key, prefix) {
+			delete(o.nodes, key)
+		}
+	}
+}
+
+// shadows reports whether existing entries occupy a path,
+// either directly or through a non-directory ancestor.
+func (o *MemFS) shadows(name string) bool {
+	name = cleanName(name)
+
+	if _, ok := o.nodes[name]; ok {
+		return true
+	}
+
+	for dir := path.Dir(name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if node, ok := o.nodes[dir]; ok && !node.mode.IsDir() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Descendants lists the entries beneath a directory.
+func (o *MemFS) Descendants(name string) []string {
+	name = cleanName(name)
+	prefix := name + "/"
+
+	if name == "." {
+		prefix = ""
+	}
+
+	var descendants []string
+
+	for key := range o.nodes {
+		if key != "." && key != name && strings.HasPrefix(key, prefix) {
+			descendants = append(descendants, key)
+		}
+	}
+
+	return descendants
+}
+
+// resolve follows symlinks in every path element.
+func (o *MemFS) resolve(name string, followLast bool) (string, *memNode, error) {
+	hops := 0
+	elements := strings.Split(cleanName(name), "/")
+	resolved := "."
+
+	for i := 0; i < len(elements); i++ {
+		element := elements[i]
+
+		if element == "." || element == "" {
+			continue
+		}
+
+		candidate := path.Join(resolved, element)
+		node, ok := o.nodes[candidate]
+
+		if !ok {
+			return "", nil, fs.ErrNotExist
+		}
+
+		last := i == len(elements)-1
+
+		if node.mode&fs.ModeSymlink != 0 && (!last || followLast) {
+			hops++
+
+			if hops > MaxSymlinkHops {
+				return "", nil, fmt.Errorf("too many levels of symbolic links: %v", name)
+			}
+
+			base := resolved
+
+			if strings.HasPrefix(node.target, "/") {
+				base = "."
+			}
+
+			rest := append(strings.Split(cleanName(path.Join("/", base, node.target)), "/"), elements[i+1:]...)
+			elements = rest
+			i = -1
+			resolved = "."
+			continue
+		}
+
+		resolved = candidate
+	}
+
+	return resolved, o.nodes[resolved], nil
+}
+
+// Open opens a file, following symlinks.
+func (o *MemFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	resolved, node, err := o.resolve(name, true)
+
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	info := memFileInfo{name: path.Base(resolved), node: node}
+
+	if node.mode.IsDir() {
+		entries, err2 := o.readDir(resolved)
+
+		if err2 != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err2}
+		}
+
+		return &memDir{info: info, entries: entries}, nil
+	}
+
+	if node.data == nil && node.size > 0 {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fmt.Errorf("content omitted")}
+	}
+
+	return &memFile{info: info, reader: bytes.NewReader(node.data)}, nil
+}
+
+// readDir lists the direct children of a resolved directory.
+func (o *MemFS) readDir(dir string) ([]fs.DirEntry, error) {
+	prefix := dir + "/"
+
+	if dir == "." {
+		prefix = ""
+	}
+
+	var entries []fs.DirEntry
+
+	for key, node := range o.nodes {
+		if key == "." || !strings.HasPrefix(key, prefix) || strings.Contains(key[len(prefix):], "/") {
+			continue
+		}
+
+		info := memFileInfo{name: path.Base(key), node: node}
+
+		if node.mode&fs.ModeSymlink != 0 {
+			if _, target, err := o.resolve(key, true); err == nil {
+				info.node = target
+			}
+		}
+
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	return entries, nil
+}
+
+// ReadDir lists a directory, following symlinks.
+func (o *MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	resolved, node, err := o.resolve(name, true)
+
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+
+	if !node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fmt.Errorf("not a directory")}
+	}
+
+	return o.readDir(resolved)
+}
+
+// memFileInfo adapts memNode to fs.FileInfo.
+type memFileInfo struct {
+	name string
+	node *memNode
+}
+
+// Name yields the base name.
+func (o memFileInfo) Name() string {
+	return o.name
+}
+
+// Size yields the content length.
+func (o memFileInfo) Size() int64 {
+	return o.node.size
+}
+
+// Mode yields the file type and permissions.
+func (o memFileInfo) Mode() fs.FileMode {
+	return o.node.mode
+}
+
+// ModTime yields the modification timestamp.
+func (o memFileInfo) ModTime() time.Time {
+	return o.node.modTime
+}
+
+// IsDir reports whether the entry is a directory.
+func (o memFileInfo) IsDir() bool {
+	return o.node.mode.IsDir()
+}
+
+// Sys yields nil.
+func (o memFileInfo) Sys() interface{} { return nil }
+
+// memFile models an open regular file.
+type memFile struct {
+	info   memFileInfo
+	reader *bytes.Reader
+}
+
+// Stat describes the file.
+func (o *memFile) Stat() (fs.FileInfo, error) {
+	return o.info, nil
+}
+
+// Read reads file content.
+func (o *memFile) Read(p []byte) (int, error) {
+	return o.reader.Read(p)
+}
+
+// Close releases the file.
+func (o *memFile) Close() error {
+	return nil
+}
+
+// memDir models an open directory.
+type memDir struct {
+	info    memFileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+// Stat describes the directory.
+func (o *memDir) Stat() (fs.FileInfo, error) {
+	return o.info, nil
+}
+
+// Read rejects directory reads.
+func (o *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: o.info.name, Err: fmt.Errorf("is a directory")}
+}
+
+// Close releases the directory.
+func (o *memDir) Close() error {
+	return nil
+}
+
+// ReadDir lists directory entries.
+func (o *memDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := o.entries[o.offset:]
+
+	if n <= 0 {
+		o.offset = len(o.entries)
+		return remaining, nil
+	}
+
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+
+	o.offset += n
+	return remaining[:n], nil
+}
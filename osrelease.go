@@ -0,0 +1,206 @@
+package cicada
+
+import (
+	"github.com/Masterminds/semver"
+
+	"bufio"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// OsReleasePaths enumerates os-release file locations, in descending priority,
+// relative to the root filesystem.
+var OsReleasePaths = []string{
+	"etc/os-release",
+	"usr/lib/os-release",
+}
+
+// OsReleaseProducts maps os-release ID values to endoflife.date products.
+var OsReleaseProducts = map[string]string{
+	"almalinux":     "almalinux",
+	"alpine":        "alpine",
+	"amzn":          "amazon-linux",
+	"centos":        "centos",
+	"debian":        "debian",
+	"fedora":        "fedora",
+	"freebsd":       "freebsd",
+	"linuxmint":     "linuxmint",
+	"nixos":         "nixos",
+	"ol":            "oracle-linux",
+	"opensuse":      "opensuse",
+	"opensuse-leap": "opensuse",
+	"poky":          "yocto",
+	"rhel":          "rhel",
+	"rocky":         "rocky-linux",
+	"ubuntu":        "ubuntu",
+}
+
+// OsRelease models the identifying fields of an os-release file.
+type OsRelease struct {
+	// ID denotes the distribution identifier.
+	ID string
+
+	// IDLike denotes related distribution identifiers,
+	// from closest to most distant.
+	IDLike []string
+
+	// VersionID denotes the distribution version.
+	//
+	// Blank indicates a rolling or pre-release distribution.
+	VersionID string
+
+	// VersionCodename denotes the distribution release nickname.
+	VersionCodename string
+
+	// UbuntuCodename denotes the Ubuntu release nickname
+	// which an Ubuntu derivative is based on.
+	UbuntuCodename string
+}
+
+// ParseOsRelease decodes os-release content.
+//
+// Comments, blank lines, and unknown fields are skipped.
+func ParseOsRelease(r io.Reader) (*OsRelease, error) {
+	var osRelease OsRelease
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+
+		if !ok {
+			continue
+		}
+
+		value = UnquoteOsReleaseValue(value)
+
+		switch key {
+		case "ID":
+			osRelease.ID = value
+		case "ID_LIKE":
+			osRelease.IDLike = strings.Fields(value)
+		case "VERSION_ID":
+			osRelease.VersionID = value
+		case "VERSION_CODENAME":
+			osRelease.VersionCodename = value
+		case "UBUNTU_CODENAME":
+			osRelease.UbuntuCodename = value
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return &osRelease, nil
+}
+
+// UnquoteOsReleaseValue decodes shell style quoted os-release values.
+func UnquoteOsReleaseValue(value string) string {
+	switch {
+	case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+
+		return value[1 : len(value)-1]
+	case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+		return value[1 : len(value)-1]
+	default:
+		return value
+	}
+}
+
+// ReadOsRelease parses the os-release file of the live host.
+//
+// nil indicates no os-release file is present.
+func ReadOsRelease() (*OsRelease, error) {
+	return ReadOsReleaseFS(os.DirFS("/"))
+}
+
+// ReadOsReleaseFS parses the first os-release file found in OsReleasePaths,
+// within a root filesystem.
+//
+// nil indicates no os-release file is present.
+func ReadOsReleaseFS(fsys fs.FS) (*OsRelease, error) {
+	for _, pth := range OsReleasePaths {
+		f, err := fsys.Open(pth)
+
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			if err2 := f.Close(); err2 != nil {
+				log.Print(err2)
+			}
+		}()
+
+		return ParseOsRelease(f)
+	}
+
+	return nil, nil
+}
+
+// Product identifies the endoflife.date product name,
+// consulting ID_LIKE when ID is unknown.
+//
+// Blank indicates an unknown distribution.
+func (o OsRelease) Product() string {
+	if product, ok := OsReleaseProducts[o.ID]; ok {
+		return product
+	}
+
+	for _, id := range o.IDLike {
+		if product, ok := OsReleaseProducts[id]; ok {
+			return product
+		}
+	}
+
+	return ""
+}
+
+// Derived reports whether Product falls back to an ID_LIKE parent distribution.
+func (o OsRelease) Derived() bool {
+	_, ok := OsReleaseProducts[o.ID]
+	return !ok && o.Product() != ""
+}
+
+// Version parses VERSION_ID.
+//
+// Derivative distributions number their releases independently of the parent,
+// so derived releases report no version.
+//
+// nil indicates a missing VERSION_ID, or a derived distribution.
+func (o OsRelease) Version() (*semver.Version, error) {
+	if o.VersionID == "" || o.Derived() {
+		return nil, nil
+	}
+
+	return semver.NewVersion(o.VersionID)
+}
+
+// Codename identifies the release nickname in terms of Product.
+//
+// Ubuntu derivatives supply the parent release nickname via UBUNTU_CODENAME.
+func (o OsRelease) Codename() string {
+	if o.Derived() && o.Product() == "ubuntu" && o.UbuntuCodename != "" {
+		return o.UbuntuCodename
+	}
+
+	return o.VersionCodename
+}
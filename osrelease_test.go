@@ -0,0 +1,141 @@
+package cicada_test
+
+import (
+	"github.com/mcandre/cicada"
+
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseOsRelease(t *testing.T) {
+	content := `PRETTY_NAME="Debian GNU/Linux trixie/sid"
+NAME="Debian GNU/Linux"
+VERSION_CODENAME=trixie
+ID=debian
+`
+
+	osRelease, err := cicada.ParseOsRelease(strings.NewReader(content))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if osRelease.Product() != "debian" || osRelease.VersionCodename != "trixie" {
+		t.Errorf("Expected debian trixie, got: %v", osRelease)
+	}
+
+	versionP, err := osRelease.Version()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if versionP != nil {
+		t.Errorf("Expected no version for debian testing, got: %v", versionP)
+	}
+}
+
+func TestOsReleaseProductIDLike(t *testing.T) {
+	content := `ID="pop"
+ID_LIKE="ubuntu debian"
+VERSION_ID="22.04"
+VERSION_CODENAME="jammy"
+UBUNTU_CODENAME="jammy"
+`
+
+	osRelease, err := cicada.ParseOsRelease(strings.NewReader(content))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if osRelease.Product() != "ubuntu" {
+		t.Errorf("Expected ubuntu via ID_LIKE, got: %v", osRelease.Product())
+	}
+
+	if !osRelease.Derived() {
+		t.Errorf("Expected pop to derive from ubuntu")
+	}
+
+	versionP, err := osRelease.Version()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if versionP != nil {
+		t.Errorf("Expected no version for derived distribution, got: %v", versionP)
+	}
+
+	if osRelease.Codename() != "jammy" {
+		t.Errorf("Expected jammy, got: %v", osRelease.Codename())
+	}
+}
+
+func TestOsReleaseOracleLinux(t *testing.T) {
+	content := `NAME="Oracle Linux Server"
+ID="ol"
+ID_LIKE="fedora"
+VERSION_ID="8.9"
+`
+
+	osRelease, err := cicada.ParseOsRelease(strings.NewReader(content))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if osRelease.Product() != "oracle-linux" {
+		t.Errorf("Expected oracle-linux, got: %v", osRelease.Product())
+	}
+
+	if osRelease.Derived() {
+		t.Errorf("Expected oracle linux to map directly")
+	}
+}
+
+func TestScanOsReleaseDerivative(t *testing.T) {
+	index := loadProject(t, map[string]string{
+		"lc/ubuntu.yaml": "- version: \"7\"\n  codename: Wily Werewolf\n  expiration: \"2016-07-28\"\n" +
+			"- version: \"22.4\"\n  codename: Jammy Jellyfish\n  expiration: \"2027-04-01\"\n",
+		"lc/debian.yaml": "- version: \"12\"\n  codename: Bookworm\n  expiration: \"2026-06-10\"\n",
+	})
+
+	t2025 := time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	elementary := cicada.OsRelease{
+		ID:              "elementary",
+		IDLike:          []string{"ubuntu"},
+		VersionID:       "7",
+		VersionCodename: "horus",
+		UbuntuCodename:  "jammy",
+	}
+
+	warningP, err := index.ScanOsRelease(elementary, t2025)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if warningP != nil {
+		t.Errorf("Expected no warning for elementary 7 on jammy, got: %v", *warningP)
+	}
+
+	kali := cicada.OsRelease{
+		ID:              "kali",
+		IDLike:          []string{"debian"},
+		VersionID:       "2023.4",
+		VersionCodename: "kali-rolling",
+	}
+
+	warningP, err = index.ScanOsRelease(kali, t2025)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if warningP != nil {
+		t.Errorf("Expected no warning for kali, got: %v", *warningP)
+	}
+}
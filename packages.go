@@ -0,0 +1,312 @@
+package cicada
+
+import (
+	"github.com/Masterminds/semver"
+
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// PackageManagerDpkg identifies Debian package databases.
+const PackageManagerDpkg = "dpkg"
+
+// PackageManagerApk identifies Alpine package databases.
+const PackageManagerApk = "apk"
+
+// PackageManagerRpm identifies RPM package databases.
+const PackageManagerRpm = "rpm"
+
+// DpkgStatusPath denotes the dpkg database, relative to the root filesystem.
+const DpkgStatusPath = "var/lib/dpkg/status"
+
+// ApkInstalledPath denotes the apk database, relative to the root filesystem.
+const ApkInstalledPath = "lib/apk/db/installed"
+
+// RpmDatabasePaths enumerates RPM database directories,
+// relative to the root filesystem.
+var RpmDatabasePaths = []string{
+	"var/lib/rpm",
+	"usr/lib/sysimage/rpm",
+}
+
+// RpmQueryFormat renders RPM package entries as tab separated names and versions.
+const RpmQueryFormat = "%{NAME}\\t%{EPOCHNUM}:%{VERSION}\\n"
+
+// PackageVersionPattern extracts the upstream release from package version strings,
+// skipping any epoch and distribution revision.
+var PackageVersionPattern = regexp.MustCompile(`^(?:[0-9]+:)?(?P<Version>[0-9]+(\.[0-9]+){0,2})`)
+
+// PackageMapping associates package names with a product.
+type PackageMapping struct {
+	// Pattern matches dpkg, rpm, and apk package names.
+	Pattern *regexp.Regexp
+
+	// Product denotes an endoflife.date product name.
+	Product string
+}
+
+// PackageMappings enumerates the curated package name mappings.
+//
+// Packages matching no mapping are not checked.
+var PackageMappings = []PackageMapping{
+	{Pattern: regexp.MustCompile(`^ansible(-core)?$`), Product: "ansible"},
+	{Pattern: regexp.MustCompile(`^(apache2|httpd)$`), Product: "apache"},
+	{Pattern: regexp.MustCompile(`^(docker-ce|docker-engine|docker\.io)$`), Product: "docker-engine"},
+	{Pattern: regexp.MustCompile(`^firefox(-esr)?$`), Product: "firefox"},
+	{Pattern: regexp.MustCompile(`^(go|golang(-[0-9.]+)?(-go)?)$`), Product: "go"},
+	{Pattern: regexp.MustCompile(`^(java-[0-9.]+-)?openjdk-?[0-9]*(-(jre|jdk))?(-headless)?$`), Product: "java"},
+	{Pattern: regexp.MustCompile(`^mariadb-server(-core)?(-[0-9.]+)?$`), Product: "mariadb"},
+	{Pattern: regexp.MustCompile(`^mongodb-org-server$`), Product: "mongodb"},
+	{Pattern: regexp.MustCompile(`^(mysql-server(-core)?(-[0-9.]+)?|mysql-community-server)$`), Product: "mysql"},
+	{Pattern: regexp.MustCompile(`^nginx(-core|-light|-full)?$`), Product: "nginx"},
+	{Pattern: regexp.MustCompile(`^nodejs[0-9]*$`), Product: "nodejs"},
+	{Pattern: regexp.MustCompile(`^(openssl|openssl-libs|libssl[0-9.]+)$`), Product: "openssl"},
+	{Pattern: regexp.MustCompile(`^php[0-9.]*(-cli)?$`), Product: "php"},
+	{Pattern: regexp.MustCompile(`^postgresql-?[0-9]*(-server)?$`), Product: "postgresql"},
+	{Pattern: regexp.MustCompile(`^python3(\.?[0-9]+)?(-minimal)?$`), Product: "python"},
+	{Pattern: regexp.MustCompile(`^redis(-server)?$`), Product: "redis"},
+	{Pattern: regexp.MustCompile(`^ruby[0-9.]*$`), Product: "ruby"},
+}
+
+// PackageProduct maps a package name to a product,
+// according to PackageMappings.
+//
+// Blank indicates no mapping.
+func PackageProduct(name string) string {
+	for _, mapping := range PackageMappings {
+		if mapping.Pattern.MatchString(name) {
+			return mapping.Product
+		}
+	}
+
+	return ""
+}
+
+// InstalledPackage models a package database entry.
+type InstalledPackage struct {
+	// Manager denotes the package manager: "dpkg", "rpm", or "apk".
+	Manager string
+
+	// Name denotes the package name.
+	Name string
+
+	// Version denotes the raw package version.
+	Version string
+}
+
+// SemVer parses the upstream release of the package version.
+func (o InstalledPackage) SemVer() (*semver.Version, error) {
+	match := PackageVersionPattern.FindStringSubmatch(o.Version)
+
+	if match == nil {
+		return nil, fmt.Errorf("unparseable %v package version: %v %v", o.Manager, o.Name, o.Version)
+	}
+
+	return semver.NewVersion(match[PackageVersionPattern.SubexpIndex("Version")])
+}
+
+// ParseDpkgStatus decodes dpkg status databases.
+//
+// Packages not fully installed are skipped.
+func ParseDpkgStatus(r io.Reader) ([]InstalledPackage, error) {
+	var packages []InstalledPackage
+	var pkg InstalledPackage
+	var installed bool
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+
+	flush := func() {
+		if installed && pkg.Name != "" && pkg.Version != "" {
+			pkg.Manager = PackageManagerDpkg
+			packages = append(packages, pkg)
+		}
+
+		pkg = InstalledPackage{}
+		installed = false
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if line == "" {
+			flush()
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+
+		if !ok || strings.HasPrefix(line, " ") {
+			continue
+		}
+
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "Package":
+			pkg.Name = value
+		case "Version":
+			pkg.Version = value
+		case "Status":
+			installed = strings.HasSuffix(value, " installed")
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	flush()
+	return packages, nil
+}
+
+// ParseApkInstalled decodes apk installed databases.
+func ParseApkInstalled(r io.Reader) ([]InstalledPackage, error) {
+	var packages []InstalledPackage
+	var pkg InstalledPackage
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+
+	flush := func() {
+		if pkg.Name != "" && pkg.Version != "" {
+			pkg.Manager = PackageManagerApk
+			packages = append(packages, pkg)
+		}
+
+		pkg = InstalledPackage{}
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if line == "" {
+			flush()
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "P":
+			pkg.Name = value
+		case "V":
+			pkg.Version = value
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	flush()
+	return packages, nil
+}
+
+// PackageDatabase models a package database format.
+type PackageDatabase struct {
+	// Path denotes the database location, relative to the root filesystem.
+	Path string
+
+	// Parse decodes the database.
+	Parse func(io.Reader) ([]InstalledPackage, error)
+}
+
+// PackageDatabases enumerates the supported package databases.
+var PackageDatabases = []PackageDatabase{
+	{Path: DpkgStatusPath, Parse: ParseDpkgStatus},
+	{Path: ApkInstalledPath, Parse: ParseApkInstalled},
+}
+
+// ReadPackagesFS lists the packages recorded in any PackageDatabases
+// within a root filesystem.
+func ReadPackagesFS(fsys fs.FS) ([]InstalledPackage, error) {
+	var packages []InstalledPackage
+
+	for _, database := range PackageDatabases {
+		pth := database.Path
+		f, err := fsys.Open(pth)
+
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		databasePackages, err := database.Parse(f)
+
+		if err2 := f.Close(); err2 != nil && err == nil {
+			err = err2
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", pth, err)
+		}
+
+		packages = append(packages, databasePackages...)
+	}
+
+	return packages, nil
+}
+
+// ReadRpmPackages lists the packages recorded in the RPM database of a root directory,
+// using the host rpm executable.
+//
+// nil indicates no RPM database is present, or the host lacks rpm.
+func ReadRpmPackages(ctx context.Context, dir string, timeout Duration) ([]InstalledPackage, error) {
+	var found bool
+
+	for _, pth := range RpmDatabasePaths {
+		if fi, err := os.Stat(filepath.Join(dir, pth)); err == nil && fi.IsDir() {
+			found = true
+		}
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	query := VersionQuery{Command: []string{"rpm", "--root", dir, "-qa", "--queryformat", RpmQueryFormat}}
+
+	if _, err := query.Locate(); err != nil {
+		log.Printf("rpm database found, but rpm is not installed; skipping rpm packages in: %v\n", dir)
+		return nil, nil
+	}
+
+	var result QueryResult
+
+	if err := query.Run(ctx, timeout, &result); err != nil {
+		return nil, err
+	}
+
+	if result.Status != "" {
+		return nil, fmt.Errorf("rpm query %v: %v", result.Status, result.Detail())
+	}
+
+	var packages []InstalledPackage
+
+	for _, line := range strings.Split(result.Output, "\n") {
+		name, version, ok := strings.Cut(line, "\t")
+
+		if !ok {
+			continue
+		}
+
+		packages = append(packages, InstalledPackage{Manager: PackageManagerRpm, Name: name, Version: version})
+	}
+
+	return packages, nil
+}
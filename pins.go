@@ -0,0 +1,425 @@
+package cicada
+
+import (
+	"github.com/BurntSushi/toml"
+	"github.com/Masterminds/semver"
+
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ToolVersionsBase denotes asdf version pin files.
+const ToolVersionsBase = ".tool-versions"
+
+// PinFileTools maps single tool version pin files to tool names.
+var PinFileTools = map[string]string{
+	".go-version":        "go",
+	".java-version":      "java",
+	".node-version":      "nodejs",
+	".nvmrc":             "nodejs",
+	".python-version":    "python",
+	".ruby-version":      "ruby",
+	".terraform-version": "terraform",
+}
+
+// MiseConfigBases enumerates mise configuration files.
+var MiseConfigBases = []string{
+	"mise.toml",
+	".mise.toml",
+}
+
+// PinToolProducts maps version manager tool names to endoflife.date products.
+//
+// Tools matching no entry are checked against any product of the same name.
+var PinToolProducts = map[string]string{
+	"dotnet-core": "dotnet",
+	"golang":      "go",
+	"node":        "nodejs",
+	"postgres":    "postgresql",
+}
+
+// PinVersionPattern extracts versions from pin values,
+// skipping any distribution prefix, such as ruby-3.1.2 or temurin-17.0.8+7.
+var PinVersionPattern = regexp.MustCompile(`(?P<Version>[0-9]+(\.[0-9]+){0,2})`)
+
+// PinIgnores enumerates pin values that denote no particular version.
+var PinIgnores = []string{
+	"system",
+	"latest",
+	"stable",
+	"node",
+	"lts/*",
+}
+
+// ToolPin models a version pin,
+// or a runtime version declared by a language manifest.
+type ToolPin struct {
+	// Tool denotes the version manager tool name.
+	Tool string
+
+	// Version denotes the raw pin value.
+	Version string
+
+	// Line denotes the one-based line number.
+	Line int
+}
+
+// Product maps the pinned tool to an endoflife.date product.
+func (o ToolPin) Product() string {
+	if product, ok := PinToolProducts[o.Tool]; ok {
+		return product
+	}
+
+	return o.Tool
+}
+
+// Resolve parses the pin value into a version or release codename.
+//
+// For example, the nvm alias lts/hydrogen yields codename hydrogen.
+//
+// A false result indicates the pin denotes no particular version.
+func (o ToolPin) Resolve() (*semver.Version, string, bool) {
+	value := strings.TrimSpace(o.Version)
+
+	for _, ignore := range PinIgnores {
+		if value == ignore {
+			return nil, "", false
+		}
+	}
+
+	if strings.HasPrefix(value, "ref:") || strings.HasPrefix(value, "path:") {
+		return nil, "", false
+	}
+
+	if codename, ok := strings.CutPrefix(value, "lts/"); ok {
+		return nil, codename, true
+	}
+
+	match := PinVersionPattern.FindStringSubmatch(value)
+
+	if match == nil {
+		return nil, "", false
+	}
+
+	version, err := semver.NewVersion(match[PinVersionPattern.SubexpIndex("Version")])
+
+	if err != nil {
+		return nil, "", false
+	}
+
+	return version, "", true
+}
+
+// stripPinComment removes trailing # comments.
+func stripPinComment(line string) string {
+	if i := strings.Index(line, "#"); i >= 0 {
+		line = line[:i]
+	}
+
+	return strings.TrimSpace(line)
+}
+
+// ParseToolVersions decodes asdf .tool-versions files.
+//
+// Each line names a tool, followed by one or more versions, in order of preference.
+// Only the preferred version is reported.
+func ParseToolVersions(r io.Reader) ([]ToolPin, error) {
+	var pins []ToolPin
+	scanner := bufio.NewScanner(r)
+	var lineNumber int
+
+	for scanner.Scan() {
+		lineNumber++
+		fields := strings.Fields(stripPinComment(scanner.Text()))
+
+		if len(fields) < 2 {
+			continue
+		}
+
+		pins = append(pins, ToolPin{Tool: fields[0], Version: fields[1], Line: lineNumber})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return pins, nil
+}
+
+// ParseVersionFile decodes single tool version files, such as .nvmrc.
+//
+// Each non-blank line denotes a version.
+func ParseVersionFile(r io.Reader, tool string) ([]ToolPin, error) {
+	var pins []ToolPin
+	scanner := bufio.NewScanner(r)
+	var lineNumber int
+
+	for scanner.Scan() {
+		lineNumber++
+		value := stripPinComment(scanner.Text())
+
+		if value == "" {
+			continue
+		}
+
+		pins = append(pins, ToolPin{Tool: tool, Version: value, Line: lineNumber})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return pins, nil
+}
+
+// ParseMiseConfig decodes the [tools] table of mise.toml files.
+//
+// Tool values may be strings, arrays of strings, or tables with a version key.
+// Values spanning multiple lines are skipped.
+func ParseMiseConfig(r io.Reader) ([]ToolPin, error) {
+	var pins []ToolPin
+	scanner := bufio.NewScanner(r)
+	var lineNumber int
+	var inTools bool
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "[") {
+			header, _, _ := strings.Cut(line, "#")
+			inTools = strings.TrimSpace(header) == "[tools]"
+			continue
+		}
+
+		if !inTools || line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var entry map[string]interface{}
+
+		if _, err := toml.Decode(line, &entry); err != nil {
+			continue
+		}
+
+		for tool, value := range entry {
+			var version string
+
+			switch v := value.(type) {
+			case string:
+				version = v
+			case []interface{}:
+				if len(v) != 0 {
+					version, _ = v[0].(string)
+				}
+			case map[string]interface{}:
+				version, _ = v["version"].(string)
+			}
+
+			if version != "" {
+				pins = append(pins, ToolPin{Tool: tool, Version: version, Line: lineNumber})
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return pins, nil
+}
+
+// ParsePinFile decodes any supported version pin file, by base name.
+//
+// A false result indicates an unsupported file.
+func ParsePinFile(pth string) ([]ToolPin, bool, error) {
+	base := filepath.Base(pth)
+	var parse func(io.Reader) ([]ToolPin, error)
+
+	if tool, ok := PinFileTools[base]; ok {
+		parse = func(r io.Reader) ([]ToolPin, error) {
+			return ParseVersionFile(r, tool)
+		}
+	}
+
+	if base == ToolVersionsBase {
+		parse = ParseToolVersions
+	}
+
+	for _, miseBase := range MiseConfigBases {
+		if base == miseBase {
+			parse = ParseMiseConfig
+		}
+	}
+
+	if parse == nil {
+		return nil, false, nil
+	}
+
+	f, err := os.Open(pth)
+
+	if err != nil {
+		return nil, true, err
+	}
+
+	defer func() {
+		if err2 := f.Close(); err2 != nil {
+			log.Print(err2)
+		}
+	}()
+
+	pins, err := parse(f)
+	return pins, true, err
+}
+
+// PinWarnings models deprecation findings for version pin files and manifests.
+type PinWarnings struct {
+	// Debug controls whether additional logging is enabled.
+	Debug bool
+
+	// Parse decodes supported files, reporting false for unsupported files.
+	Parse func(pth string) ([]ToolPin, bool, error)
+
+	// Warnings denotes any dead pinned versions.
+	Warnings []string
+
+	// root denotes the project directory.
+	root string
+
+	// t denotes the current timestamp.
+	t time.Time
+
+	// components denotes version schedules,
+	// keyed on component name.
+	components map[string][]Schedule
+}
+
+// Walk is a callback for filepath.Walk to lint version pin files and manifests.
+func (o *PinWarnings) Walk(pth string, fi os.FileInfo, err error) error {
+	if err != nil {
+		return err
+	}
+
+	if Ignore(pth) || fi.IsDir() {
+		return nil
+	}
+
+	pins, ok, err := o.Parse(pth)
+
+	if !ok {
+		return nil
+	}
+
+	if err != nil {
+		log.Printf("warning: skipping unreadable file: %v\n", err)
+		return nil
+	}
+
+	rel, err := filepath.Rel(o.root, pth)
+
+	if err != nil {
+		rel = pth
+	}
+
+	for _, pin := range pins {
+		location := fmt.Sprintf("%v:%d", rel, pin.Line)
+		product := pin.Product()
+		schedules, ok := o.components[product]
+
+		if !ok {
+			if o.Debug {
+				log.Printf("skipping unknown pinned tool: %v: %v\n", pin.Tool, location)
+			}
+
+			continue
+		}
+
+		versionP, codename, ok := pin.Resolve()
+
+		if !ok {
+			if o.Debug {
+				log.Printf("skipping unversioned pin: %v %v: %v\n", pin.Tool, pin.Version, location)
+			}
+
+			continue
+		}
+
+		if o.Debug {
+			log.Printf("detected pinned %v %v: %v\n", product, pin.Version, location)
+		}
+
+		warningP := ScanPinnedComponent(product, versionP, codename, schedules, o.t)
+
+		if warningP != nil {
+			o.Warnings = append(o.Warnings, fmt.Sprintf("%v at %v", *warningP, location))
+		}
+	}
+
+	return nil
+}
+
+// ScanPinFiles analyzes version pin files,
+// such as .tool-versions and .nvmrc,
+// within the project directory.
+func (o Index) ScanPinFiles(t time.Time) ([]string, error) {
+	pinWarnings := PinWarnings{
+		Debug:      o.Debug,
+		Parse:      ParsePinFile,
+		root:       o.configDir,
+		components: o.components,
+		t:          t,
+	}
+
+	if err := filepath.Walk(o.configDir, pinWarnings.Walk); err != nil {
+		return pinWarnings.Warnings, err
+	}
+
+	return pinWarnings.Warnings, nil
+}
+
+// ScanManifests analyzes the runtime versions declared by language manifests,
+// such as go.mod and package.json,
+// within the project directory.
+//
+// Version ranges are checked by their lowest allowed version.
+func (o Index) ScanManifests(t time.Time) ([]string, error) {
+	pinWarnings := PinWarnings{
+		Debug:      o.Debug,
+		Parse:      ParseManifest,
+		root:       o.configDir,
+		components: o.components,
+		t:          t,
+	}
+
+	if err := filepath.Walk(o.configDir, pinWarnings.Walk); err != nil {
+		return pinWarnings.Warnings, err
+	}
+
+	return pinWarnings.Warnings, nil
+}
+
+// ScanLockfiles analyzes the framework and library versions resolved by dependency lockfiles,
+// such as package-lock.json and Gemfile.lock,
+// within the project directory.
+func (o Index) ScanLockfiles(t time.Time) ([]string, error) {
+	pinWarnings := PinWarnings{
+		Debug:      o.Debug,
+		Parse:      ParseLockfile,
+		root:       o.configDir,
+		components: o.components,
+		t:          t,
+	}
+
+	if err := filepath.Walk(o.configDir, pinWarnings.Walk); err != nil {
+		return pinWarnings.Warnings, err
+	}
+
+	return pinWarnings.Warnings, nil
+}
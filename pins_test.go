@@ -0,0 +1,127 @@
+package cicada_test
+
+import (
+	"github.com/mcandre/cicada"
+
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseToolVersions(t *testing.T) {
+	content := `# runtimes
+nodejs 16.20.2 system
+golang 1.21.0
+
+java temurin-17.0.8+7
+`
+
+	pins, err := cicada.ParseToolVersions(strings.NewReader(content))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pins) != 3 {
+		t.Fatalf("Expected three pins, got: %v", pins)
+	}
+
+	if pins[1].Product() != "go" || pins[1].Line != 3 {
+		t.Errorf("Expected golang to map to go on line 3, got: %v %v", pins[1].Product(), pins[1].Line)
+	}
+
+	versionP, _, ok := pins[2].Resolve()
+
+	if !ok || versionP.String() != "17.0.8" {
+		t.Errorf("Expected java 17.0.8, got: %v", versionP)
+	}
+}
+
+func TestParseMiseConfig(t *testing.T) {
+	content := `[env]
+NODE_ENV = "production"
+
+[tools]
+node = "lts/hydrogen"
+python = ["3.8", "3.11"]
+ruby = { version = "3.0.6" }
+`
+
+	pins, err := cicada.ParseMiseConfig(strings.NewReader(content))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pins) != 3 {
+		t.Fatalf("Expected three pins, got: %v", pins)
+	}
+
+	versionP, codename, ok := pins[0].Resolve()
+
+	if !ok || versionP != nil || codename != "hydrogen" || pins[0].Product() != "nodejs" {
+		t.Errorf("Expected nodejs codename hydrogen, got: %v %v %v", pins[0].Product(), versionP, codename)
+	}
+
+	if pins[1].Version != "3.8" || pins[1].Line != 6 {
+		t.Errorf("Expected preferred python 3.8 on line 6, got: %v", pins[1])
+	}
+
+	if pins[2].Version != "3.0.6" {
+		t.Errorf("Expected ruby 3.0.6, got: %v", pins[2])
+	}
+}
+
+func TestParseMiseConfigCommentedHeader(t *testing.T) {
+	content := `  [tools]  # runtimes
+  node = "18.19.0"
+
+[env]  # settings
+NODE_ENV = "production"
+`
+
+	pins, err := cicada.ParseMiseConfig(strings.NewReader(content))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pins) != 1 || pins[0].Tool != "node" || pins[0].Version != "18.19.0" || pins[0].Line != 2 {
+		t.Errorf("Expected node 18.19.0 on line 2, got: %v", pins)
+	}
+}
+
+func TestToolPinResolveIgnores(t *testing.T) {
+	for _, value := range []string{"system", "latest", "lts/*", "ref:main", "path:/opt/node"} {
+		if _, _, ok := (cicada.ToolPin{Tool: "nodejs", Version: value}).Resolve(); ok {
+			t.Errorf("Expected pin %v to denote no particular version", value)
+		}
+	}
+}
+
+func TestScanPinFiles(t *testing.T) {
+	index := loadProject(t, map[string]string{
+		"lc/nodejs.yaml":       "- version: \"16\"\n  codename: Gallium\n  expiration: \"2023-09-11\"\n",
+		".nvmrc":               "16.20.2\n",
+		"legacy/.nvmrc":        "lts/gallium\n",
+		"broken/.nvmrc":        "lts/(\n",
+		"tools/.tool-versions": "nodejs 16.20.2\n",
+	})
+
+	warnings, err := index.ScanPinFiles(time.Now())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"end of life for nodejs 16.20.2 on 2023-09-11 at .nvmrc:1",
+		"end of life for nodejs gallium on 2023-09-11 at legacy/.nvmrc:1",
+		"end of life for nodejs 16.20.2 on 2023-09-11 at tools/.tool-versions:1",
+	}
+
+	if !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("Expected %v, got: %v", expected, warnings)
+	}
+}
@@ -0,0 +1,32 @@
+package cicada
+
+import (
+	"sync"
+)
+
+// RunJobs calls f for each index in [0, n),
+// with up to jobs calls running concurrently.
+func RunJobs(n int, jobs int, f func(i int)) {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	semaphore := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func(i int) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+
+			f(i)
+		}(i)
+	}
+
+	wg.Wait()
+}
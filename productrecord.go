@@ -9,7 +9,7 @@ import (
 )
 
 // SemVerPattern matches semantic versions.
-var SemVerPattern = regexp.MustCompile(`^(?P<semver>[0-9]+(\.[0-9](\.[0-9])?)?).*$`)
+var SemVerPattern = regexp.MustCompile(`^(?P<semver>[0-9]+(\.[0-9]+(\.[0-9]+)?)?).*$`)
 
 // ProductRecords models endoflife.date product detail records.
 type ProductRecords []map[string]interface{}
@@ -46,8 +46,8 @@ func ProductRecordsToSchedules(name string, records ProductRecords) ([]Schedule,
 			}
 
 			version = v
-		} else if c, ok := cycle.(int); ok {
-			majorString := strconv.Itoa(c)
+		} else if c, ok := cycle.(float64); ok {
+			majorString := strconv.Itoa(int(c))
 			v, err := semver.NewVersion(majorString)
 
 			if err != nil {
@@ -57,12 +57,24 @@ func ProductRecordsToSchedules(name string, records ProductRecords) ([]Schedule,
 			version = v
 		}
 
+		if version == nil {
+			continue
+		}
+
 		schedule := Schedule{
 			Name:     name,
 			Codename: cn,
 			Version:  *version,
 		}
 
+		// lts is either a boolean or the date the series entered LTS.
+		switch lts := record["lts"].(type) {
+		case bool:
+			schedule.LTS = lts
+		case string:
+			schedule.LTS = lts != ""
+		}
+
 		eol := record["eol"]
 
 		var expiration *time.Time
@@ -0,0 +1,54 @@
+package cicada_test
+
+import (
+	"github.com/Masterminds/semver"
+	"github.com/mcandre/cicada"
+
+	"testing"
+	"time"
+)
+
+func TestProductRecordsToSchedulesMultiDigit(t *testing.T) {
+	records := cicada.ProductRecords{
+		{"cycle": "1.22", "eol": false},
+		{"cycle": "1.21", "eol": "2024-08-13"},
+		{"cycle": "3.12", "eol": "2028-10-31"},
+		{"cycle": "3.1", "eol": "2012-04-09"},
+		{"cycle": "22.04", "codename": "Jammy Jellyfish", "eol": "2027-04-01"},
+		{"cycle": "1.2.10", "eol": "2010-01-01"},
+	}
+
+	schedules, err := cicada.ProductRecordsToSchedules("go", records)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"1.22", "1.21", "3.12", "3.1", "22.04", "1.2.10"}
+
+	if len(schedules) != len(expected) {
+		t.Fatalf("Expected %v schedules, got: %v", len(expected), schedules)
+	}
+
+	for i, schedule := range schedules {
+		if schedule.Version.Original() != expected[i] {
+			t.Errorf("Expected cycle %v, got: %v", expected[i], schedule.Version.Original())
+		}
+	}
+
+	if schedules[1].Version.Minor() != 21 || schedules[4].Version.Minor() != 4 || schedules[5].Version.Patch() != 10 {
+		t.Errorf("Expected multi-digit minors and patches, got: %v", schedules)
+	}
+
+	version, err := semver.NewVersion("1.21")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t2025 := time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	if warningP := cicada.ScanComponent("go", version, "", schedules, t2025); warningP == nil || *warningP != "end of life for go 1.21.0 on 2024-08-13" {
+		t.Errorf("Expected go 1.21 to match the 1.21 series, got: %v", warningP)
+	}
+}
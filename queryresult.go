@@ -0,0 +1,144 @@
+package cicada
+
+import (
+	"github.com/Masterminds/semver"
+
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ExcerptLength bounds diagnostic output excerpts.
+const ExcerptLength = 200
+
+// Excerpt condenses diagnostic output to a single, bounded line.
+func Excerpt(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+
+	if len(s) > ExcerptLength {
+		s = s[:ExcerptLength] + "..."
+	}
+
+	return s
+}
+
+// QueryStatus classifies version query outcomes.
+type QueryStatus string
+
+// QueryStatusFound denotes a successfully identified version.
+const QueryStatusFound QueryStatus = "found"
+
+// QueryStatusNotInstalled denotes a missing executable, file, or environment variable.
+const QueryStatusNotInstalled QueryStatus = "not installed"
+
+// QueryStatusFailed denotes a command that exited non-zero,
+// or an unreadable file.
+const QueryStatusFailed QueryStatus = "failed"
+
+// QueryStatusTimedOut denotes a command that exceeded its timeout.
+const QueryStatusTimedOut QueryStatus = "timed out"
+
+// QueryStatusNoMatch denotes output that did not match the query pattern.
+const QueryStatusNoMatch QueryStatus = "matched no pattern"
+
+// QueryStatusUnparseable denotes a version string that is not a semantic version.
+const QueryStatusUnparseable QueryStatus = "unparseable"
+
+// QueryResult models version query outcomes.
+type QueryResult struct {
+	// Status classifies the outcome.
+	Status QueryStatus
+
+	// Query describes the version query.
+	Query string
+
+	// Location denotes the resolved executable, file, or environment variable.
+	//
+	// Blank indicates the component was not found.
+	Location string
+
+	// Timeout denotes the effective query timeout.
+	Timeout Duration
+
+	// ExitCode denotes the command exit status.
+	ExitCode int
+
+	// Stderr denotes an excerpt of the command error output.
+	Stderr string
+
+	// Output denotes the command output.
+	Output string
+
+	// VersionString denotes the extracted version text.
+	VersionString string
+
+	// Version denotes the identified version,
+	// when the status is found.
+	Version *semver.Version
+}
+
+// Parse extracts a version from the output.
+//
+// pattern optionally captures a Version group from the first matching line.
+// nil indicates the full output, sans right trim,
+// is treated as a version string.
+func (o *QueryResult) Parse(pattern *regexp.Regexp) {
+	versionString := strings.TrimRight(o.Output, "\r\n")
+
+	if pattern != nil {
+		scanner := bufio.NewScanner(strings.NewReader(o.Output))
+		versionIndex := pattern.SubexpIndex("Version")
+		var foundVersion bool
+
+		for scanner.Scan() {
+			match := pattern.FindStringSubmatch(scanner.Text())
+
+			if versionIndex >= 0 && len(match) > versionIndex {
+				foundVersion = true
+				versionString = match[versionIndex]
+				break
+			}
+		}
+
+		if !foundVersion {
+			o.Status = QueryStatusNoMatch
+			return
+		}
+	}
+
+	o.VersionString = versionString
+	version, err := semver.NewVersion(versionString)
+
+	if versionString == "" || err != nil {
+		o.Status = QueryStatusUnparseable
+		return
+	}
+
+	o.Status = QueryStatusFound
+	o.Version = version
+}
+
+// Detail describes the outcome, for diagnostics.
+func (o QueryResult) Detail() string {
+	switch o.Status {
+	case QueryStatusFound:
+		return o.Location
+	case QueryStatusNotInstalled:
+		return fmt.Sprintf("not found: %v", o.Query)
+	case QueryStatusFailed:
+		if o.ExitCode == 0 {
+			return o.Stderr
+		}
+
+		return fmt.Sprintf("exit code %d: %v", o.ExitCode, o.Stderr)
+	case QueryStatusTimedOut:
+		return fmt.Sprintf("exceeded %v", o.Timeout)
+	case QueryStatusNoMatch:
+		return fmt.Sprintf("output: %v", Excerpt(o.Output))
+	case QueryStatusUnparseable:
+		return fmt.Sprintf("version: %q", o.VersionString)
+	default:
+		return ""
+	}
+}
@@ -11,7 +11,21 @@ var EnvironmentIsLinux = true
 
 // RecognizeOs identifies the environment,
 // as an endoflife.date product name.
+//
+// The os-release file takes precedence over system vendor information.
 func RecognizeOs() (*string, error) {
+	osReleaseP, err := ReadOsRelease()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if osReleaseP != nil {
+		if product := osReleaseP.Product(); product != "" {
+			return &product, nil
+		}
+	}
+
 	var si sysinfo.SysInfo
 	si.GetSysInfo()
 	return &si.OS.Vendor, nil
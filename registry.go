@@ -0,0 +1,478 @@
+package cicada
+
+import (
+	"github.com/Masterminds/semver"
+
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DockerHubRegistry denotes the registry host of Docker Hub image references.
+const DockerHubRegistry = "registry-1.docker.io"
+
+// ImageVersionLabel denotes the OCI label of the packaged software version.
+const ImageVersionLabel = "org.opencontainers.image.version"
+
+// RegistryTimeout bounds awaiting each registry response.
+//
+// Response bodies, such as large layers, are bounded by the request context instead.
+const RegistryTimeout = 2 * time.Minute
+
+// RegistryManifestMediaTypes lists the accepted manifest formats.
+var RegistryManifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// RegistryChallengeParamPattern extracts key="value" parameters from WWW-Authenticate challenges.
+var RegistryChallengeParamPattern = regexp.MustCompile(`(?P<Key>[A-Za-z]+)="(?P<Value>[^"]*)"`)
+
+// RegistryRepository locates the registry host and repository path of an image reference.
+//
+// Following Docker conventions, a leading path component containing a dot or colon,
+// or named localhost, denotes a registry host.
+// Other images reside on Docker Hub, with official images under library/.
+func RegistryRepository(image Image) (string, string) {
+	repository := image.Name
+
+	if image.Registry != "" {
+		repository = fmt.Sprintf("%v/%v", image.Registry, image.Name)
+	}
+
+	host, rest, ok := strings.Cut(repository, "/")
+
+	if ok && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		if host != "docker.io" && host != "index.docker.io" {
+			return host, rest
+		}
+
+		repository = rest
+	}
+
+	if !strings.Contains(repository, "/") {
+		repository = fmt.Sprintf("library/%v", repository)
+	}
+
+	return DockerHubRegistry, repository
+}
+
+// RegistryScheme selects plain HTTP for loopback registries,
+// such as a local registry:2 container, and HTTPS otherwise.
+func RegistryScheme(host string) string {
+	hostname := host
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+
+	if hostname == "localhost" {
+		return "http"
+	}
+
+	if ip := net.ParseIP(hostname); ip != nil && ip.IsLoopback() {
+		return "http"
+	}
+
+	return "https"
+}
+
+// RegistryClient reads images over the OCI distribution API.
+type RegistryClient struct {
+	// HTTP denotes the underlying client.
+	HTTP *http.Client
+
+	// StopEarly skips lower layers once RegistryImageComplete holds.
+	// Runtimes installed only in lower layers then go unnoticed.
+	StopEarly bool
+
+	// tokens caches bearer tokens,
+	// keyed on host and repository.
+	tokens map[string]string
+
+	// images caches flattened images,
+	// keyed on host, repository, and manifest digest.
+	images map[string]*ImageArchive
+}
+
+// NewRegistryClient constructs a RegistryClient.
+func NewRegistryClient() *RegistryClient {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = RegistryTimeout
+
+	return &RegistryClient{
+		HTTP:   &http.Client{Transport: transport},
+		tokens: make(map[string]string),
+		images: make(map[string]*ImageArchive),
+	}
+}
+
+// authenticate obtains a pull token in response to a bearer challenge.
+func (o *RegistryClient) authenticate(ctx context.Context, challenge string, repository string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("unsupported registry authentication: %v", challenge)
+	}
+
+	values := make(map[string]string)
+
+	for _, match := range RegistryChallengeParamPattern.FindAllStringSubmatch(params, -1) {
+		values[strings.ToLower(match[1])] = match[2]
+	}
+
+	realm, err := url.Parse(values["realm"])
+
+	if err != nil || values["realm"] == "" {
+		return "", fmt.Errorf("invalid registry authentication realm: %v", challenge)
+	}
+
+	scope := values["scope"]
+
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%v:pull", repository)
+	}
+
+	query := realm.Query()
+	query.Set("scope", scope)
+
+	if service := values["service"]; service != "" {
+		query.Set("service", service)
+	}
+
+	realm.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	res, err := o.HTTP.Do(req)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		if err2 := res.Body.Close(); err2 != nil {
+			log.Print(err2)
+		}
+	}()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return "", fmt.Errorf("get: %v returned status code: %v", realm.Redacted(), res.StatusCode)
+	}
+
+	var grant struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+
+	if err2 := json.NewDecoder(io.LimitReader(res.Body, MaxImageMetadataSize)).Decode(&grant); err2 != nil {
+		return "", err2
+	}
+
+	if grant.Token != "" {
+		return grant.Token, nil
+	}
+
+	return grant.AccessToken, nil
+}
+
+// get requests a repository resource, such as manifests/latest,
+// answering any bearer challenge once.
+//
+// Callers close the response body.
+func (o *RegistryClient) get(ctx context.Context, host string, repository string, resource string, accept []string) (*http.Response, error) {
+	u := fmt.Sprintf("%v://%v/v2/%v/%v", RegistryScheme(host), host, repository, resource)
+	key := fmt.Sprintf("%v/%v", host, repository)
+
+	do := func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(accept) != 0 {
+			req.Header.Set("Accept", strings.Join(accept, ", "))
+		}
+
+		if token, ok := o.tokens[key]; ok {
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+		}
+
+		return o.HTTP.Do(req)
+	}
+
+	res, err := do()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusUnauthorized {
+		challenge := res.Header.Get("WWW-Authenticate")
+
+		if err2 := res.Body.Close(); err2 != nil {
+			log.Print(err2)
+		}
+
+		token, err2 := o.authenticate(ctx, challenge, repository)
+
+		if err2 != nil {
+			return nil, err2
+		}
+
+		o.tokens[key] = token
+		res, err = do()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		if err2 := res.Body.Close(); err2 != nil {
+			log.Print(err2)
+		}
+
+		return nil, fmt.Errorf("get: %v returned status code: %v", u, res.StatusCode)
+	}
+
+	return res, nil
+}
+
+// getMetadata reads a repository resource, bounded by MaxImageMetadataSize.
+func (o *RegistryClient) getMetadata(ctx context.Context, host string, repository string, resource string, accept []string) ([]byte, error) {
+	res, err := o.get(ctx, host, repository, resource, accept)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err2 := res.Body.Close(); err2 != nil {
+			log.Print(err2)
+		}
+	}()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, MaxImageMetadataSize+1))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(body) > MaxImageMetadataSize {
+		return nil, fmt.Errorf("%v exceeds %d bytes", resource, MaxImageMetadataSize)
+	}
+
+	return body, nil
+}
+
+// getJSON decodes a repository resource, bounded by MaxImageMetadataSize.
+func (o *RegistryClient) getJSON(ctx context.Context, host string, repository string, resource string, accept []string, v interface{}) error {
+	body, err := o.getMetadata(ctx, host, repository, resource, accept)
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+// RegistryImageComplete reports whether a partially flattened image
+// supplies an os-release file and a package database,
+// such that lower layers need not be read.
+func RegistryImageComplete(fsys fs.FS) bool {
+	var osRelease bool
+
+	for _, pth := range OsReleasePaths {
+		if _, err := fs.Stat(fsys, pth); err == nil {
+			osRelease = true
+		}
+	}
+
+	if !osRelease {
+		return false
+	}
+
+	for _, database := range PackageDatabases {
+		if _, err := fs.Stat(fsys, database.Path); err == nil {
+			return true
+		}
+	}
+
+	return HasRpmDatabase(fsys)
+}
+
+// ReadImage flattens a registry image,
+// selecting the manifest for the image platform from any image index.
+//
+// Layers are read from the top down.
+// With StopEarly, reading stops once RegistryImageComplete reports
+// an os-release file and a package database.
+// Images are cached by manifest digest,
+// so repeated references to an image are read once.
+//
+// keep reports whether to retain the content of large regular files.
+// Small files are always retained.
+func (o *RegistryClient) ReadImage(ctx context.Context, image Image, keep func(name string) bool) (*ImageArchive, error) {
+	host, repository := RegistryRepository(image)
+	reference := image.Digest
+
+	if reference == "" {
+		reference = image.Tag
+	}
+
+	if reference == "" {
+		reference = "latest"
+	}
+
+	body, err := o.getMetadata(ctx, host, repository, fmt.Sprintf("manifests/%v", reference), RegistryManifestMediaTypes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(body)
+	digest := fmt.Sprintf("sha256:%v", hex.EncodeToString(sum[:]))
+	var manifest OCIManifest
+
+	if err2 := json.Unmarshal(body, &manifest); err2 != nil {
+		return nil, err2
+	}
+
+	// Bound nested image indices.
+	for depth := 0; len(manifest.Manifests) != 0; depth++ {
+		if depth >= 4 {
+			return nil, fmt.Errorf("image index nesting too deep")
+		}
+
+		descriptorP, err2 := SelectPlatformManifest(manifest.Manifests, image.Platform)
+
+		if err2 != nil {
+			return nil, err2
+		}
+
+		digest = descriptorP.Digest
+		manifest = OCIManifest{}
+
+		if err3 := o.getJSON(ctx, host, repository, fmt.Sprintf("manifests/%v", digest), RegistryManifestMediaTypes, &manifest); err3 != nil {
+			return nil, err3
+		}
+	}
+
+	key := fmt.Sprintf("%v/%v@%v", host, repository, digest)
+
+	if archiveP, ok := o.images[key]; ok {
+		return archiveP, nil
+	}
+
+	var config ImageConfig
+
+	if manifest.Config.Digest != "" {
+		if err2 := o.getJSON(ctx, host, repository, fmt.Sprintf("blobs/%v", manifest.Config.Digest), nil, &config); err2 != nil {
+			return nil, err2
+		}
+	}
+
+	archive := ImageArchive{FS: NewMemFS(), Env: config.Config.Env, Labels: config.Config.Labels}
+	whiteouts := NewWhiteouts()
+
+	for i := len(manifest.Layers) - 1; i >= 0 && !(o.StopEarly && RegistryImageComplete(archive.FS)); i-- {
+		layer := manifest.Layers[i]
+		res, err2 := o.get(ctx, host, repository, fmt.Sprintf("blobs/%v", layer.Digest), nil)
+
+		if err2 != nil {
+			return nil, err2
+		}
+
+		err2 = ApplyLowerLayer(archive.FS, res.Body, keep, whiteouts)
+
+		if err3 := res.Body.Close(); err3 != nil {
+			log.Print(err3)
+		}
+
+		if err2 != nil {
+			return nil, fmt.Errorf("%v: %v", layer.Digest, err2)
+		}
+	}
+
+	o.images[key] = &archive
+	return &archive, nil
+}
+
+// ScanRegistryImage generates LTS warnings for a Dockerfile base image,
+// according to the image content in its registry:
+// the os-release file, package databases, well-known paths and environment variables,
+// and any org.opencontainers.image.version label.
+func (o Index) ScanRegistryImage(ctx context.Context, client *RegistryClient, image Image, t time.Time) ([]string, error) {
+	archive, err := client.ReadImage(ctx, image, o.ImageKeep())
+
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	warningOs, err := o.ScanRootOs(archive.FS, t)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if warningOs != nil {
+		warnings = append(warnings, *warningOs)
+	}
+
+	root, cleanup, err := archive.Root()
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer cleanup()
+	resultsApplications, err := o.ScanRootApplications(*root, t)
+
+	if err != nil {
+		return nil, err
+	}
+
+	warnings = append(warnings, resultsApplications...)
+	resultsPackages, err := o.ScanPackages(ctx, *root, t)
+
+	if err != nil {
+		return nil, err
+	}
+
+	warnings = append(warnings, resultsPackages...)
+
+	if label, ok := archive.Labels[ImageVersionLabel]; ok {
+		product := ImageProduct(image.Name)
+
+		if schedules, ok2 := o.components[product]; ok2 {
+			if versionP, err2 := semver.NewVersion(label); err2 == nil {
+				if warningP := ScanPinnedComponent(product, versionP, "", schedules, t); warningP != nil {
+					warnings = append(warnings, *warningP)
+				}
+			} else if o.Debug {
+				log.Printf("skipping unparseable image version label %v: '%v'\n", label, image)
+			}
+		}
+	}
+
+	return UniqueWarnings(warnings), nil
+}
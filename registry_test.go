@@ -0,0 +1,280 @@
+package cicada_test
+
+import (
+	"github.com/mcandre/cicada"
+
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistryRepository(t *testing.T) {
+	for reference, expected := range map[string]string{
+		"python:3.8":                        cicada.DockerHubRegistry + " library/python",
+		"docker.io/library/node:18":         cicada.DockerHubRegistry + " library/node",
+		"bitnami/postgresql:11":             cicada.DockerHubRegistry + " bitnami/postgresql",
+		"localhost:5000/alpine:3.16":        "localhost:5000 alpine",
+		"registry.example.com/team/app:1.0": "registry.example.com team/app",
+	} {
+		host, repository := cicada.RegistryRepository(cicada.ParseImageReference(reference))
+
+		if actual := host + " " + repository; actual != expected {
+			t.Errorf("Expected %v to locate %q, got: %q", reference, expected, actual)
+		}
+	}
+}
+
+func digestOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("sha256:%v", hex.EncodeToString(sum[:]))
+}
+
+func TestRegistryClientReadImage(t *testing.T) {
+	layer := gzipBytes(t, writeTar(t, []tarEntry{
+		{name: "etc/", typeflag: tar.TypeDir},
+		{name: "etc/os-release", typeflag: tar.TypeReg, content: "ID=debian\nVERSION_ID=\"10\"\n"},
+	}))
+
+	config, err := json.Marshal(map[string]interface{}{
+		"config": map[string]interface{}{
+			"Env":    []string{"PYTHON_VERSION=3.7.17"},
+			"Labels": map[string]string{cicada.ImageVersionLabel: "3.7.17"},
+		},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := json.Marshal(cicada.OCIManifest{
+		MediaType: "application/vnd.oci.image.manifest.v1+json",
+		Config:    cicada.OCIDescriptor{Digest: digestOf(config)},
+		Layers:    []cicada.OCIDescriptor{{Digest: digestOf(layer)}},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := json.Marshal(cicada.OCIIndex{
+		Manifests: []cicada.OCIDescriptor{
+			{Digest: "sha256:0000", Platform: &cicada.OCIPlatform{OS: "windows", Architecture: "amd64"}},
+			{Digest: digestOf(manifest), Platform: &cicada.OCIPlatform{OS: "linux", Architecture: "arm64"}},
+		},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resources := map[string][]byte{
+		"/v2/team/python/manifests/internal":                            index,
+		fmt.Sprintf("/v2/team/python/manifests/%v", digestOf(manifest)): manifest,
+		fmt.Sprintf("/v2/team/python/blobs/%v", digestOf(config)):       config,
+		fmt.Sprintf("/v2/team/python/blobs/%v", digestOf(layer)):        layer,
+	}
+
+	var server *httptest.Server
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:team/python:pull" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			fmt.Fprint(w, `{"token": "secret"}`)
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%v/token",service="test"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		body, ok := resources[r.URL.Path]
+
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if _, err := w.Write(body); err != nil {
+			t.Error(err)
+		}
+	}))
+
+	defer server.Close()
+
+	image := cicada.ParseImageReference(fmt.Sprintf("%v/team/python:internal", strings.TrimPrefix(server.URL, "http://")))
+	image.Platform = "linux/arm64"
+
+	archive, err := cicada.NewRegistryClient().ReadImage(context.Background(), image, func(string) bool { return false })
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	osRelease, err := fs.ReadFile(archive.FS, "etc/os-release")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(osRelease), "VERSION_ID=\"10\"") {
+		t.Errorf("Expected debian 10 os-release, got: %q", osRelease)
+	}
+
+	if archive.Labels[cicada.ImageVersionLabel] != "3.7.17" || len(archive.Env) != 1 {
+		t.Errorf("Expected image configuration labels and env, got: %v %v", archive.Labels, archive.Env)
+	}
+}
+
+func TestRegistryClientReadImageTopDown(t *testing.T) {
+	base := writeTar(t, []tarEntry{
+		{name: "etc/os-release", typeflag: tar.TypeReg, content: "ID=debian\nVERSION_ID=\"10\"\n"},
+	})
+
+	top := writeTar(t, []tarEntry{
+		{name: "etc/os-release", typeflag: tar.TypeReg, content: "ID=debian\nVERSION_ID=\"12\"\n"},
+		{name: "var/lib/dpkg/status", typeflag: tar.TypeReg, content: "Package: base-files\nStatus: install ok installed\nVersion: 12.4\n"},
+	})
+
+	config := []byte(`{"config":{}}`)
+
+	manifest, err := json.Marshal(cicada.OCIManifest{
+		MediaType: "application/vnd.oci.image.manifest.v1+json",
+		Config:    cicada.OCIDescriptor{Digest: digestOf(config)},
+		Layers:    []cicada.OCIDescriptor{{Digest: digestOf(base)}, {Digest: digestOf(top)}},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resources := map[string][]byte{
+		"/v2/library/debian/manifests/12":                            manifest,
+		fmt.Sprintf("/v2/library/debian/blobs/%v", digestOf(config)): config,
+		fmt.Sprintf("/v2/library/debian/blobs/%v", digestOf(base)):   base,
+		fmt.Sprintf("/v2/library/debian/blobs/%v", digestOf(top)):    top,
+	}
+
+	requests := make(map[string]int)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		body, ok := resources[r.URL.Path]
+
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if _, err := w.Write(body); err != nil {
+			t.Error(err)
+		}
+	}))
+
+	defer server.Close()
+
+	image := cicada.ParseImageReference(fmt.Sprintf("%v/library/debian:12", strings.TrimPrefix(server.URL, "http://")))
+	client := cicada.NewRegistryClient()
+	client.StopEarly = true
+
+	for i := 0; i < 2; i++ {
+		archive, err2 := client.ReadImage(context.Background(), image, func(string) bool { return false })
+
+		if err2 != nil {
+			t.Fatal(err2)
+		}
+
+		osRelease, err2 := fs.ReadFile(archive.FS, "etc/os-release")
+
+		if err2 != nil {
+			t.Fatal(err2)
+		}
+
+		if !strings.Contains(string(osRelease), "VERSION_ID=\"12\"") {
+			t.Errorf("Expected top layer os-release, got: %q", osRelease)
+		}
+	}
+
+	if n := requests[fmt.Sprintf("/v2/library/debian/blobs/%v", digestOf(base))]; n != 0 {
+		t.Errorf("Expected no base layer download once os-release and dpkg status are found, got: %v", n)
+	}
+
+	if n := requests[fmt.Sprintf("/v2/library/debian/blobs/%v", digestOf(top))]; n != 1 {
+		t.Errorf("Expected one top layer download across repeated reads, got: %v", n)
+	}
+}
+
+func TestRegistryClientReadImageLowestLayerRuntime(t *testing.T) {
+	base := writeTar(t, []tarEntry{
+		{name: "usr/local/go/VERSION", typeflag: tar.TypeReg, content: "go1.19.13\n"},
+	})
+
+	top := writeTar(t, []tarEntry{
+		{name: "etc/os-release", typeflag: tar.TypeReg, content: "ID=debian\nVERSION_ID=\"12\"\n"},
+		{name: "var/lib/dpkg/status", typeflag: tar.TypeReg, content: "Package: base-files\nStatus: install ok installed\nVersion: 12.4\n"},
+	})
+
+	config := []byte(`{"config":{}}`)
+
+	manifest, err := json.Marshal(cicada.OCIManifest{
+		MediaType: "application/vnd.oci.image.manifest.v1+json",
+		Config:    cicada.OCIDescriptor{Digest: digestOf(config)},
+		Layers:    []cicada.OCIDescriptor{{Digest: digestOf(base)}, {Digest: digestOf(top)}},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resources := map[string][]byte{
+		"/v2/team/app/manifests/1.0":                           manifest,
+		fmt.Sprintf("/v2/team/app/blobs/%v", digestOf(config)): config,
+		fmt.Sprintf("/v2/team/app/blobs/%v", digestOf(base)):   base,
+		fmt.Sprintf("/v2/team/app/blobs/%v", digestOf(top)):    top,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := resources[r.URL.Path]
+
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if _, err := w.Write(body); err != nil {
+			t.Error(err)
+		}
+	}))
+
+	defer server.Close()
+
+	image := cicada.ParseImageReference(fmt.Sprintf("%v/team/app:1.0", strings.TrimPrefix(server.URL, "http://")))
+	archive, err := cicada.NewRegistryClient().ReadImage(context.Background(), image, func(string) bool { return false })
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	goVersion, err := fs.ReadFile(archive.FS, "usr/local/go/VERSION")
+
+	if err != nil {
+		t.Fatalf("Expected lowest layer runtime, got: %v", err)
+	}
+
+	if string(goVersion) != "go1.19.13\n" {
+		t.Errorf("Expected go1.19.13, got: %q", goVersion)
+	}
+}
@@ -0,0 +1,42 @@
+package cicada
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// JSONReport models machine readable scan results.
+type JSONReport struct {
+	// Warnings denotes end of life findings.
+	Warnings []string `json:"warnings"`
+}
+
+// Report formats scan results.
+func (o Index) Report(w io.Writer, warnings []string) error {
+	switch o.Format {
+	case FormatJSON:
+		report := JSONReport{Warnings: warnings}
+
+		if report.Warnings == nil {
+			report.Warnings = []string{}
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	default:
+		for _, warning := range warnings {
+			if _, err := fmt.Fprintf(w, "warning: %v\n", warning); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// Failed reports whether scan results reach the fail threshold.
+func (o Index) Failed(warnings []string) bool {
+	return o.FailThreshold > 0 && len(warnings) >= o.FailThreshold
+}
@@ -0,0 +1,410 @@
+package cicada
+
+import (
+	"github.com/Masterminds/semver"
+
+	"context"
+	"errors"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// KernelModulesPaths enumerates kernel module directories,
+// relative to the root filesystem.
+var KernelModulesPaths = []string{
+	"lib/modules",
+	"usr/lib/modules",
+}
+
+// KernelReleasePattern extracts versions from kernel release names.
+var KernelReleasePattern = regexp.MustCompile(`^(?P<Version>[0-9]+\.[0-9]+(\.[0-9]+)?)`)
+
+// WellKnownQueries supplements configured version queries when scanning root filesystems,
+// keyed on product.
+//
+// The environment variables follow the conventions of official container images.
+var WellKnownQueries = map[string][]VersionQuery{
+	"go": {
+		{File: "/usr/local/go/VERSION", Pattern: regexp.MustCompile(`^go(?P<Version>[0-9\.]+)`)},
+		{Env: "GOLANG_VERSION"},
+	},
+	"java": {
+		{Env: "JAVA_VERSION", Pattern: regexp.MustCompile(`^(jdk-?)?(?P<Version>[0-9]+(\.[0-9]+)*)`)},
+	},
+	"mongodb": {
+		{Env: "MONGO_VERSION"},
+	},
+	"nginx": {
+		{Env: "NGINX_VERSION"},
+	},
+	"nodejs": {
+		{Env: "NODE_VERSION"},
+	},
+	"php": {
+		{Env: "PHP_VERSION"},
+	},
+	"postgresql": {
+		{Env: "PG_VERSION", Pattern: regexp.MustCompile(`^(?P<Version>[0-9]+(\.[0-9]+)?)`)},
+	},
+	"python": {
+		{Env: "PYTHON_VERSION"},
+	},
+	"redis": {
+		{Env: "REDIS_VERSION"},
+	},
+	"ruby": {
+		{Env: "RUBY_VERSION"},
+	},
+}
+
+// RootQueries lists the version queries applicable to root filesystems, for a product.
+//
+// Configured file and env queries precede WellKnownQueries.
+// Command queries are omitted, so that nothing is executed from the root filesystem.
+func (o Index) RootQueries(app string) []VersionQuery {
+	var queries []VersionQuery
+
+	if query, ok := o.VersionQueries[app]; ok && query.Source() != VersionQuerySourceCommand {
+		queries = append(queries, query)
+	}
+
+	return append(queries, WellKnownQueries[app]...)
+}
+
+// RootPathPatterns lists the globs of files read when scanning root filesystems,
+// relative to the root.
+func (o Index) RootPathPatterns() []string {
+	patterns := append([]string{}, OsReleasePaths...)
+
+	for _, database := range PackageDatabases {
+		patterns = append(patterns, database.Path)
+	}
+
+	for _, pth := range RpmDatabasePaths {
+		patterns = append(patterns, path.Join(pth, "*"))
+	}
+
+	var apps []string
+
+	for app := range o.VersionQueries {
+		apps = append(apps, app)
+	}
+
+	for app := range WellKnownQueries {
+		apps = append(apps, app)
+	}
+
+	for _, app := range apps {
+		for _, query := range o.RootQueries(app) {
+			if query.Source() == VersionQuerySourceFile {
+				patterns = append(patterns, strings.TrimPrefix(query.File, "/"))
+			}
+		}
+	}
+
+	return patterns
+}
+
+// ReadKernelFS identifies the newest kernel installed within a root filesystem,
+// according to its module directories.
+//
+// nil indicates no kernel is installed,
+// as is typical of container images.
+func ReadKernelFS(fsys fs.FS) (*semver.Version, error) {
+	var newest *semver.Version
+
+	for _, pth := range KernelModulesPaths {
+		entries, err := fs.ReadDir(fsys, pth)
+
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			match := KernelReleasePattern.FindStringSubmatch(entry.Name())
+
+			if !entry.IsDir() || match == nil {
+				continue
+			}
+
+			version, err2 := semver.NewVersion(match[KernelReleasePattern.SubexpIndex("Version")])
+
+			if err2 != nil {
+				continue
+			}
+
+			if newest == nil || version.GreaterThan(newest) {
+				newest = version
+			}
+		}
+	}
+
+	return newest, nil
+}
+
+// ScanRootOs analyzes the operating system of a root filesystem for any LTS concerns.
+func (o Index) ScanRootOs(fsys fs.FS, t time.Time) (*string, error) {
+	osReleaseP, err := ReadOsReleaseFS(fsys)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if osReleaseP == nil {
+		log.Println("no os-release file found in root filesystem")
+		return nil, nil
+	}
+
+	if osReleaseP.Product() == "" {
+		log.Printf("unknown os-release distribution: %v\n", osReleaseP.ID)
+		return nil, nil
+	}
+
+	return o.ScanOsRelease(*osReleaseP, t)
+}
+
+// ScanRootKernel analyzes the kernel of a root filesystem for any LTS concerns.
+func (o Index) ScanRootKernel(fsys fs.FS, t time.Time) (*string, error) {
+	versionP, err := ReadKernelFS(fsys)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if versionP == nil {
+		if o.Debug {
+			log.Println("no kernel modules found in root filesystem; skipping")
+		}
+
+		return nil, nil
+	}
+
+	schedules, ok := o.components["linux"]
+
+	if !ok {
+		log.Println("no known support schedule found for product 'linux'")
+		return nil, nil
+	}
+
+	if o.Debug {
+		log.Printf("detected linux kernel: v%v\n", versionP.String())
+	}
+
+	return ScanComponent("linux", versionP, "", schedules, t), nil
+}
+
+// Root models a filesystem under inspection.
+type Root struct {
+	// FS denotes the root filesystem.
+	FS fs.FS
+
+	// Dir denotes the host directory of a mounted root filesystem.
+	//
+	// Blank indicates an in-memory root filesystem, such as a flattened image.
+	Dir string
+
+	// Environ denotes the KEY=value environment of the root filesystem,
+	// such as a container image configuration.
+	Environ []string
+}
+
+// UniqueWarnings removes duplicate warnings, preserving order.
+func UniqueWarnings(warnings []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+
+	for _, warning := range warnings {
+		if seen[warning] {
+			continue
+		}
+
+		seen[warning] = true
+		unique = append(unique, warning)
+	}
+
+	return unique
+}
+
+// ScanRootApplications analyzes the applications of a root filesystem for any LTS concerns.
+//
+// Applications are identified by file and env version queries.
+// File queries resolve relative to the root.
+// Env queries resolve against the root environment.
+func (o Index) ScanRootApplications(root Root, t time.Time) ([]string, error) {
+	var apps []string
+
+	for app := range o.components {
+		if !IsOperatingSystem(app) {
+			apps = append(apps, app)
+		}
+	}
+
+	sort.Strings(apps)
+
+	var warnings []string
+
+	for _, app := range apps {
+		for _, query := range o.RootQueries(app) {
+			var result *QueryResult
+			var err error
+
+			if query.Source() == VersionQuerySourceFile {
+				result, err = query.ReadFS(root.FS)
+			} else {
+				result, err = query.ReadEnv(root.Environ)
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			if result.Status != QueryStatusFound {
+				if o.Debug && result.Status != QueryStatusNotInstalled {
+					log.Printf("unable to identify version for app: %v: %v: %v\n", app, result.Status, result.Detail())
+				}
+
+				continue
+			}
+
+			if o.Debug {
+				log.Printf("detected application: %v v%v\n", app, result.Version.String())
+			}
+
+			warning := ScanComponent(app, result.Version, "", o.components[app], t)
+
+			if warning != nil {
+				warnings = append(warnings, *warning)
+			}
+
+			break
+		}
+	}
+
+	return warnings, nil
+}
+
+// ReadPackages lists the packages recorded in the package databases of a root filesystem.
+//
+// RPM databases are queried with the host rpm executable,
+// for root filesystems mounted at a host directory.
+func (o Index) ReadPackages(ctx context.Context, root Root) ([]InstalledPackage, error) {
+	packages, err := ReadPackagesFS(root.FS)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if root.Dir == "" {
+		return packages, nil
+	}
+
+	rpmPackages, err := ReadRpmPackages(ctx, root.Dir, o.QueryTimeout)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append(packages, rpmPackages...), nil
+}
+
+// ScanPackages analyzes installed packages for any LTS concerns,
+// catching services and libraries that lack executables on PATH.
+//
+// Only package names listed in PackageMappings are checked,
+// to avoid false positives from unrelated packages that share a product name.
+func (o Index) ScanPackages(ctx context.Context, root Root, t time.Time) ([]string, error) {
+	packages, err := o.ReadPackages(ctx, root)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+
+	for _, pkg := range packages {
+		app := PackageProduct(pkg.Name)
+		schedules, ok := o.components[app]
+
+		if !ok || IsOperatingSystem(app) {
+			continue
+		}
+
+		versionP, err2 := pkg.SemVer()
+
+		if err2 != nil {
+			if o.Debug {
+				log.Println(err2)
+			}
+
+			continue
+		}
+
+		if o.Debug {
+			log.Printf("detected %v package: %v %v as %v v%v\n", pkg.Manager, pkg.Name, pkg.Version, app, versionP.String())
+		}
+
+		warning := ScanPinnedComponent(app, versionP, "", schedules, t)
+
+		if warning != nil {
+			warnings = append(warnings, *warning)
+		}
+	}
+
+	return UniqueWarnings(warnings), nil
+}
+
+// ScanRoot generates LTS warnings for a root filesystem,
+// such as a chroot, a mounted disk, or an extracted container layer,
+// instead of the live host.
+//
+// Nothing is executed from the root filesystem.
+func (o Index) ScanRoot(ctx context.Context, root Root) ([]string, error) {
+	fsys := root.FS
+	var warnings []string
+	tNow := time.Now()
+	t := tNow.AddDate(0, o.LeadMonths, 0)
+	warningOs, err := o.ScanRootOs(fsys, t)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if warningOs != nil {
+		warnings = append(warnings, *warningOs)
+	}
+
+	warningKernel, err := o.ScanRootKernel(fsys, t)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if warningKernel != nil {
+		warnings = append(warnings, *warningKernel)
+	}
+
+	resultsApplications, err := o.ScanRootApplications(root, t)
+
+	if err != nil {
+		return nil, err
+	}
+
+	warnings = append(warnings, resultsApplications...)
+	resultsPackages, err := o.ScanPackages(ctx, root, t)
+
+	if err != nil {
+		return nil, err
+	}
+
+	warnings = append(warnings, resultsPackages...)
+	return UniqueWarnings(warnings), nil
+}
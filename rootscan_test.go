@@ -0,0 +1,170 @@
+package cicada_test
+
+import (
+	"github.com/mcandre/cicada"
+
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestReadKernelFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"lib/modules/5.10.0-28-amd64/modules.dep": &fstest.MapFile{},
+		"lib/modules/6.1.0-18-amd64/modules.dep":  &fstest.MapFile{},
+	}
+
+	versionP, err := cicada.ReadKernelFS(fsys)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if versionP == nil || versionP.String() != "6.1.0" {
+		t.Errorf("Expected newest kernel 6.1.0, got: %v", versionP)
+	}
+
+	versionP, err = cicada.ReadKernelFS(fstest.MapFS{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if versionP != nil {
+		t.Errorf("Expected no kernel, got: %v", versionP)
+	}
+}
+
+func TestParseDpkgStatus(t *testing.T) {
+	content := `Package: nginx
+Status: install ok installed
+Version: 1.22.1-9
+Description: small, powerful, scalable web/proxy server
+ nginx ("engine X") is a high-performance web and reverse proxy server.
+
+Package: redis
+Status: deinstall ok config-files
+Version: 5:7.0.15-1
+
+Package: tzdata
+Status: install ok installed
+Version: 2024a-0+deb12u1
+`
+
+	packages, err := cicada.ParseDpkgStatus(strings.NewReader(content))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(packages) != 2 || packages[0].Name != "nginx" {
+		t.Fatalf("Expected installed nginx and tzdata, got: %v", packages)
+	}
+
+	versionP, err := packages[0].SemVer()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if versionP.String() != "1.22.1" {
+		t.Errorf("Expected nginx 1.22.1, got: %v", versionP)
+	}
+}
+
+func TestParseApkInstalled(t *testing.T) {
+	content := `C:Q1abc=
+P:musl
+V:1.2.4-r2
+
+P:nodejs
+V:18.18.2-r0
+`
+
+	packages, err := cicada.ParseApkInstalled(strings.NewReader(content))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(packages) != 2 || packages[1].Name != "nodejs" || packages[1].Version != "18.18.2-r0" {
+		t.Errorf("Expected musl and nodejs, got: %v", packages)
+	}
+}
+
+func TestPackageProduct(t *testing.T) {
+	for name, product := range map[string]string{
+		"postgresql-14":         "postgresql",
+		"postgresql15-server":   "postgresql",
+		"openjdk-11-jre":        "java",
+		"java-17-openjdk":       "java",
+		"nodejs":                "nodejs",
+		"python3.11":            "python",
+		"php8.2-cli":            "php",
+		"redis-server":          "redis",
+		"libpostgresql-jdbc":    "",
+		"python3-requests-mock": "",
+	} {
+		if actual := cicada.PackageProduct(name); actual != product {
+			t.Errorf("Expected package %v to map to %q, got: %q", name, product, actual)
+		}
+	}
+
+	pkg := cicada.InstalledPackage{Manager: cicada.PackageManagerRpm, Name: "java-11-openjdk", Version: "1:11.0.22.0.7"}
+	versionP, err := pkg.SemVer()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if versionP.String() != "11.0.22" {
+		t.Errorf("Expected 11.0.22, got: %v", versionP)
+	}
+}
+
+func TestScanPackages(t *testing.T) {
+	index := loadProject(t, map[string]string{
+		"lc/postgresql.yaml": "- version: \"14\"\n  expiration: \"2026-11-12\"\n",
+		"lc/nodejs.yaml":     "- version: \"18\"\n  expiration: \"2025-04-30\"\n",
+		"lc/java.yaml":       "- version: \"11\"\n  expiration: \"2024-10-01\"\n",
+		"lc/less.yaml":       "- version: \"590\"\n  expiration: \"2000-01-01\"\n",
+	})
+
+	fsys := fstest.MapFS{
+		"var/lib/dpkg/status": &fstest.MapFile{Data: []byte(`Package: postgresql-14
+Status: install ok installed
+Version: 14.10-0ubuntu0.22.04.1
+
+Package: nodejs
+Status: install ok installed
+Version: 18.19.0-1nodesource1
+
+Package: openjdk-11-jre
+Status: install ok installed
+Version: 11.0.21+9-0ubuntu1~22.04
+
+Package: less
+Status: install ok installed
+Version: 590-1ubuntu0.22.04.1
+`)},
+	}
+
+	t2025 := time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)
+	warnings, err := index.ScanPackages(context.Background(), cicada.Root{FS: fsys}, t2025)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"end of life for nodejs 18.19.0 on 2025-04-30",
+		"end of life for java 11.0.21 on 2024-10-01",
+	}
+
+	if !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("Expected %v, got: %v", expected, warnings)
+	}
+}
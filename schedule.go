@@ -4,6 +4,7 @@ import (
 	"github.com/Masterminds/semver"
 	"gopkg.in/yaml.v3"
 
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"strings"
@@ -27,6 +28,9 @@ type Schedule struct {
 	// Zero minor is treated as matching any minor.
 	Version semver.Version `json:"version" yaml:"version"`
 
+	// LTS marks long term support release series.
+	LTS bool `json:"lts,omitempty" yaml:"lts,omitempty"`
+
 	// Expiration denotes a termination timestamp.
 	//
 	// nil indicates no known expiration.
@@ -49,7 +53,7 @@ type Schedule struct {
 // Or "1." (corresponding with "1.0").
 func (o Schedule) Match(version *semver.Version, specificity int, codename string) bool {
 	if codename != "" {
-		return regexp.MustCompile(fmt.Sprintf("(?i)%s", codename)).MatchString(o.Codename)
+		return regexp.MustCompile(fmt.Sprintf("(?i)%s", regexp.QuoteMeta(codename))).MatchString(o.Codename)
 	}
 
 	if version == nil {
@@ -69,31 +73,66 @@ func (o Schedule) Match(version *semver.Version, specificity int, codename strin
 	return v.Minor() == o.Version.Minor()
 }
 
-// MarshalYAML encodes schedules.
-func (o Schedule) MarshalYAML() (interface{}, error) {
-	type ScheduleAlias struct {
-		Name       string `yaml:"name"`
-		Version    string `yaml:"version"`
-		Expiration string `yaml:"expiration,omitempty"`
-	}
+// ScheduleAlias models the serialized form of schedules.
+type ScheduleAlias struct {
+	Name       string `json:"name" toml:"name" yaml:"name"`
+	Codename   string `json:"codename,omitempty" toml:"codename,omitempty" yaml:"codename,omitempty"`
+	Version    string `json:"version" toml:"version" yaml:"version"`
+	LTS        bool   `json:"lts,omitempty" toml:"lts,omitempty" yaml:"lts,omitempty"`
+	Expiration string `json:"expiration,omitempty" toml:"expiration,omitempty" yaml:"expiration,omitempty"`
+}
 
+// Alias converts schedules to their serialized form.
+func (o Schedule) Alias() ScheduleAlias {
 	var aux ScheduleAlias
 	aux.Name = o.Name
+	aux.Codename = o.Codename
 	aux.Version = o.Version.Original()
+	aux.LTS = o.LTS
 
 	if o.Expiration != nil {
 		aux.Expiration = o.Expiration.Format(RFC3339DateFormat)
 	}
 
-	return aux, nil
+	return aux
+}
+
+// Schedule converts serialized schedules.
+func (o ScheduleAlias) Schedule() (*Schedule, error) {
+	var schedule Schedule
+
+	if o.Expiration != "" {
+		t, err := time.Parse(RFC3339DateFormat, o.Expiration)
+
+		if err != nil {
+			return nil, err
+		}
+
+		schedule.Expiration = &t
+	}
+
+	schedule.Name = o.Name
+	schedule.Codename = o.Codename
+	schedule.LTS = o.LTS
+	version, err := semver.NewVersion(o.Version)
+
+	if err != nil {
+		return nil, err
+	}
+
+	schedule.Version = *version
+	return &schedule, nil
+}
+
+// MarshalYAML encodes schedules.
+func (o Schedule) MarshalYAML() (interface{}, error) {
+	return o.Alias(), nil
 }
 
 // UnmarshalYAML decodes schedules.
 func (o *Schedule) UnmarshalYAML(value *yaml.Node) error {
-	type ScheduleAlias struct {
-		Name       string `json:"name" yaml:"name"`
-		Version    string `json:"version" yaml:"version"`
-		Expiration string `json:"expiration,omitempty" yaml:"expiration,omitempty"`
+	if err := CheckYAMLKeys(value, "name", "codename", "version", "lts", "expiration"); err != nil {
+		return err
 	}
 
 	var aux ScheduleAlias
@@ -102,29 +141,81 @@ func (o *Schedule) UnmarshalYAML(value *yaml.Node) error {
 		return err
 	}
 
-	if aux.Expiration != "" {
-		t, err := time.Parse(RFC3339DateFormat, aux.Expiration)
+	schedule, err := aux.Schedule()
 
-		if err != nil {
-			return err
-		}
+	if err != nil {
+		return err
+	}
+
+	*o = *schedule
+	return nil
+}
+
+// MarshalJSON encodes schedules.
+func (o Schedule) MarshalJSON() ([]byte, error) {
+	return json.Marshal(o.Alias())
+}
 
-		o.Expiration = &t
+// UnmarshalJSON decodes schedules.
+func (o *Schedule) UnmarshalJSON(data []byte) error {
+	var aux ScheduleAlias
+
+	if err := DecodeJSONStrict(data, &aux); err != nil {
+		return err
 	}
 
-	o.Name = aux.Name
-	version, err := semver.NewVersion(aux.Version)
+	schedule, err := aux.Schedule()
 
 	if err != nil {
 		return err
 	}
 
-	o.Version = *version
+	*o = *schedule
 	return nil
 }
 
+// MarshalTOML encodes schedules, as inline tables.
+func (o Schedule) MarshalTOML() ([]byte, error) {
+	return MarshalTOMLInline(o.Alias())
+}
+
+// UnmarshalTOML decodes schedules.
+//
+// Expirations may be given as TOML local dates or as strings.
+func (o *Schedule) UnmarshalTOML(data interface{}) error {
+	if table, ok := data.(map[string]interface{}); ok {
+		if expiration, ok2 := table["expiration"].(time.Time); ok2 {
+			table["expiration"] = expiration.Format(RFC3339DateFormat)
+		}
+	}
+
+	dataJSON, err := json.Marshal(data)
+
+	if err != nil {
+		return err
+	}
+
+	return o.UnmarshalJSON(dataJSON)
+}
+
 // ScanComponent checks whether the given component is end of life.
 func ScanComponent(name string, version *semver.Version, codename string, schedules []Schedule, t time.Time) *string {
+	return scanComponent(name, version, codename, schedules, t, false)
+}
+
+// ScanPinnedComponent checks whether the given pinned component is end of life.
+//
+// Pinned versions, such as dependency lock entries and full image tags,
+// name a release within a series.
+// So release series named by a major version alone, such as nodejs "16",
+// match any minor, and 16.20.2 applies.
+func ScanPinnedComponent(name string, version *semver.Version, codename string, schedules []Schedule, t time.Time) *string {
+	return scanComponent(name, version, codename, schedules, t, true)
+}
+
+// scanComponent checks whether the given component is end of life,
+// optionally matching major-only series against any minor.
+func scanComponent(name string, version *semver.Version, codename string, schedules []Schedule, t time.Time, pinned bool) *string {
 	var specificity int
 
 	if version != nil {
@@ -132,7 +223,13 @@ func ScanComponent(name string, version *semver.Version, codename string, schedu
 	}
 
 	for _, schedule := range schedules {
-		if !schedule.Match(version, specificity, codename) {
+		scheduleSpecificity := specificity
+
+		if pinned && !strings.Contains(schedule.Version.Original(), ".") {
+			scheduleSpecificity = 0
+		}
+
+		if !schedule.Match(version, scheduleSpecificity, codename) {
 			continue
 		}
 
@@ -154,3 +251,23 @@ func ScanComponent(name string, version *semver.Version, codename string, schedu
 
 	return nil
 }
+
+// LatestSchedule selects the newest release series,
+// optionally limited to LTS series.
+//
+// nil indicates no matching series.
+func LatestSchedule(schedules []Schedule, lts bool) *Schedule {
+	var latest *Schedule
+
+	for i, schedule := range schedules {
+		if lts && !schedule.LTS {
+			continue
+		}
+
+		if latest == nil || schedule.Version.GreaterThan(&latest.Version) {
+			latest = &schedules[i]
+		}
+	}
+
+	return latest
+}
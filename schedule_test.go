@@ -5,6 +5,7 @@ import (
 	"github.com/mcandre/cicada"
 	"gopkg.in/yaml.v3"
 
+	"encoding/json"
 	"reflect"
 	"testing"
 	"time"
@@ -44,3 +45,141 @@ func TestScheduleYAMLCodec(t *testing.T) {
 		t.Errorf("Expected decoded schedule2: %v to equal original schedule: %v", schedule2, schedule)
 	}
 }
+
+func TestScheduleJSONCodec(t *testing.T) {
+	version, err := semver.NewVersion("2.6")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp, err := time.Parse(cicada.RFC3339DateFormat, "2022-03-31")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schedule := cicada.Schedule{
+		Name:       "Ruby",
+		Codename:   "six",
+		Version:    *version,
+		Expiration: &exp,
+	}
+
+	scheduleJSON, err := json.Marshal(schedule)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var schedule2 cicada.Schedule
+	if err := json.Unmarshal(scheduleJSON, &schedule2); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(schedule2, schedule) {
+		t.Errorf("Expected decoded schedule2: %v to equal original schedule: %v", schedule2, schedule)
+	}
+}
+
+func TestScheduleTOMLDecoding(t *testing.T) {
+	var document struct {
+		Schedules []cicada.Schedule `toml:"schedules"`
+	}
+
+	contentTOML := `schedules = [{name = "ruby", version = "2.6", expiration = 2022-03-31}]`
+
+	if err := cicada.DecodeTOMLStrict([]byte(contentTOML), &document); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(document.Schedules) != 1 || document.Schedules[0].Expiration == nil || document.Schedules[0].Expiration.Format(cicada.RFC3339DateFormat) != "2022-03-31" {
+		t.Errorf("Expected TOML local date expiration, got: %v", document.Schedules)
+	}
+}
+
+// mustSchedules builds expired schedules from version, codename pairs.
+func mustSchedules(t *testing.T, pairs ...string) []cicada.Schedule {
+	exp, err := time.Parse(cicada.RFC3339DateFormat, "2020-01-01")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var schedules []cicada.Schedule
+
+	for i := 0; i < len(pairs); i += 2 {
+		version, err2 := semver.NewVersion(pairs[i])
+
+		if err2 != nil {
+			t.Fatal(err2)
+		}
+
+		schedules = append(schedules, cicada.Schedule{Version: *version, Codename: pairs[i+1], Expiration: &exp})
+	}
+
+	return schedules
+}
+
+func TestScanComponentMatching(t *testing.T) {
+	now := time.Now()
+
+	for _, c := range []struct {
+		name      string
+		version   string
+		codename  string
+		schedules []cicada.Schedule
+		expected  bool
+	}{
+		{"ubuntu", "22.04", "", mustSchedules(t, "22.04", "jammy"), true},
+		{"ubuntu", "22.04", "", mustSchedules(t, "22.10", "kinetic", "20.04", "focal"), false},
+		{"alpine", "3.18", "", mustSchedules(t, "3.18", ""), true},
+		{"alpine", "3.18.4", "", mustSchedules(t, "3.19", ""), false},
+		{"debian", "", "bullseye", mustSchedules(t, "11", "bullseye"), true},
+		{"debian", "", "bookworm", mustSchedules(t, "11", "bullseye"), false},
+		{"debian", "10", "", mustSchedules(t, "10", "buster"), true},
+		{"nodejs", "16", "", mustSchedules(t, "16", ""), true},
+		{"nodejs", "16.20.2", "", mustSchedules(t, "16", ""), false},
+	} {
+		var versionP *semver.Version
+
+		if c.version != "" {
+			v, err := semver.NewVersion(c.version)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			versionP = v
+		}
+
+		if actual := cicada.ScanComponent(c.name, versionP, c.codename, c.schedules, now) != nil; actual != c.expected {
+			t.Errorf("Expected %v %v%v to match %v as %v", c.name, c.version, c.codename, c.schedules, c.expected)
+		}
+	}
+}
+
+func TestScanPinnedComponent(t *testing.T) {
+	now := time.Now()
+
+	for _, c := range []struct {
+		version   string
+		schedules []cicada.Schedule
+		expected  bool
+	}{
+		{"16.20.2", mustSchedules(t, "16", ""), true},
+		{"18.19.0", mustSchedules(t, "16", ""), false},
+		{"3.18.4", mustSchedules(t, "3.18", ""), true},
+		{"3.18.4", mustSchedules(t, "3.19", ""), false},
+	} {
+		version, err := semver.NewVersion(c.version)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual := cicada.ScanPinnedComponent("widget", version, "", c.schedules, now) != nil; actual != c.expected {
+			t.Errorf("Expected pinned %v to match %v as %v", c.version, c.schedules, c.expected)
+		}
+	}
+}
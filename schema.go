@@ -0,0 +1,149 @@
+package cicada
+
+import (
+	"gopkg.in/yaml.v3"
+
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// DecodeYAMLStrict decodes a YAML document,
+// rejecting any unknown fields.
+//
+// Empty documents decode to no changes.
+func DecodeYAMLStrict(contentYAML []byte, v interface{}) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(contentYAML))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	return nil
+}
+
+// CheckYAMLKeys rejects mapping keys outside of the known set.
+//
+// Custom unmarshalers apply this check,
+// because strict decoding does not propagate through yaml.Node.Decode.
+func CheckYAMLKeys(value *yaml.Node, known ...string) error {
+	if value.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i < len(value.Content); i += 2 {
+		key := value.Content[i]
+		var found bool
+
+		for _, k := range known {
+			if key.Value == k {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("line %d: field %v not found", key.Line, key.Value)
+		}
+	}
+
+	return nil
+}
+
+// YAMLMappingValue looks up the value node of a mapping key.
+//
+// nil indicates a missing key, or a node other than a mapping.
+func YAMLMappingValue(value *yaml.Node, key string) *yaml.Node {
+	if value == nil || value.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		if value.Content[i].Value == key {
+			return value.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// EditDistance computes the Levenshtein distance between two strings.
+func EditDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+// SuggestProduct finds the closest known product name to a misspelling.
+//
+// Returns blank when no product is similar.
+func (o Index) SuggestProduct(name string) string {
+	var suggestion string
+	best := 3
+
+	for product := range o.components {
+		if distance := EditDistance(name, product); distance < best {
+			best = distance
+			suggestion = product
+		}
+	}
+
+	return suggestion
+}
+
+// Lint reports suspicious configuration,
+// such as version queries for products
+// that are unknown to the lifecycle sources.
+//
+// Only configuration layers declared by the project
+// and its extended configurations are checked.
+func (o Index) Lint() []string {
+	var warnings []string
+	var products []string
+
+	for product := range o.queryLocations {
+		products = append(products, product)
+	}
+
+	sort.Strings(products)
+
+	for _, product := range products {
+		if _, ok := o.components[product]; ok {
+			continue
+		}
+
+		warning := fmt.Sprintf("%v: version query for unknown product: %v", o.queryLocations[product], product)
+
+		if suggestion := o.SuggestProduct(product); suggestion != "" {
+			warning = fmt.Sprintf("%v (did you mean %v?)", warning, suggestion)
+		}
+
+		warnings = append(warnings, warning)
+	}
+
+	return warnings
+}
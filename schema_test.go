@@ -0,0 +1,41 @@
+package cicada_test
+
+import (
+	"github.com/mcandre/cicada"
+
+	"testing"
+)
+
+func TestDecodeYAMLStrictRejectsUnknownFields(t *testing.T) {
+	var index cicada.Index
+
+	if err := cicada.DecodeYAMLStrict([]byte("lead_month: 2\n"), &index); err == nil {
+		t.Errorf("Expected unknown top level field to be rejected")
+	}
+
+	if err := cicada.DecodeYAMLStrict([]byte("version_queries:\n  go:\n    command: [\"go\", \"version\"]\n    patern: \"go(?P<Version>[0-9.]+)\"\n"), &index); err == nil {
+		t.Errorf("Expected unknown version query field to be rejected")
+	}
+
+	if err := cicada.DecodeYAMLStrict([]byte("# comments only\n"), &index); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidateVersionQueryPattern(t *testing.T) {
+	var index cicada.Index
+
+	if err := cicada.DecodeYAMLStrict([]byte("version_queries:\n  go:\n    command: [\"go\", \"version\"]\n    pattern: \"go(?P<version>[0-9.]+)\"\n"), &index); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := index.Validate(); err == nil {
+		t.Errorf("Expected pattern without Version capture group to be rejected")
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	if distance := cicada.EditDistance("nodjs", "nodejs"); distance != 1 {
+		t.Errorf("Expected distance 1, got: %v", distance)
+	}
+}
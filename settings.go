@@ -0,0 +1,317 @@
+package cicada
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// EnvironmentPrefix denotes the namespace of cicada environment variables.
+const EnvironmentPrefix = "CICADA_"
+
+// OriginDefault labels built-in setting values.
+const OriginDefault = "default"
+
+// FormatText denotes plain text reports.
+const FormatText = "text"
+
+// FormatJSON denotes JSON reports.
+const FormatJSON = "json"
+
+// DefaultFailThreshold denotes the number of warnings
+// that triggers a non-zero exit status.
+const DefaultFailThreshold = 1
+
+// Setting models a scalar configuration key,
+// which may be overridden by environment variables and command line flags.
+type Setting struct {
+	// Key denotes the configuration key.
+	Key string
+
+	// Usage documents the setting.
+	Usage string
+
+	// Bool denotes a boolean setting.
+	Bool bool
+}
+
+// Env yields the environment variable name of the setting.
+//
+// For example, CICADA_LEAD_MONTHS.
+func (o Setting) Env() string {
+	return EnvironmentPrefix + strings.ToUpper(o.Key)
+}
+
+// Flag yields the command line flag name of the setting.
+//
+// For example, lead-months.
+func (o Setting) Flag() string {
+	return strings.ReplaceAll(o.Key, "_", "-")
+}
+
+// Settings enumerates the overridable configuration keys.
+var Settings = []Setting{
+	{Key: "debug", Usage: "Enable additional logging", Bool: true},
+	{Key: "quiet", Usage: "Skip system components unlikely to be actionable", Bool: true},
+	{Key: "lead_months", Usage: "Months of early warning before end of life"},
+	{Key: "cache_dir", Usage: "Metadata cache directory, relative to the project directory"},
+	{Key: "offline", Usage: "Use cached data only, without network access", Bool: true},
+	{Key: "format", Usage: "Report format (text, json)"},
+	{Key: "fail_threshold", Usage: "Number of warnings that triggers a non-zero exit status (0 disables)"},
+	{Key: "query_timeout", Usage: "Version query timeout, such as 10s (0 disables)"},
+	{Key: "scan_timeout", Usage: "Overall scan timeout, such as 10m (0 disables)"},
+	{Key: "jobs", Usage: "Number of application version queries run concurrently"},
+	{Key: "all_installs", Usage: "Check every installation across PATH and version managers", Bool: true},
+	{Key: "packages", Usage: "Check installed package databases on Linux", Bool: true},
+	{Key: "unpinned_tags", Usage: "Report Docker images that use floating tags, such as latest", Bool: true},
+	{Key: "resolve_images", Usage: "Read Dockerfile base images from their registries", Bool: true},
+}
+
+// IsSetting reports whether a configuration key is overridable.
+func IsSetting(key string) bool {
+	for _, setting := range Settings {
+		if setting.Key == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Override models a layered setting value.
+type Override struct {
+	// Key denotes the configuration key.
+	Key string
+
+	// Value denotes the raw setting value.
+	Value string
+
+	// Origin describes where the value came from.
+	Origin string
+}
+
+// EnvironmentOverrides collects CICADA_* settings from an environment,
+// in os.Environ format.
+func EnvironmentOverrides(environ []string) []Override {
+	var overrides []Override
+
+	for _, setting := range Settings {
+		prefix := setting.Env() + "="
+
+		for _, pair := range environ {
+			if !strings.HasPrefix(pair, prefix) {
+				continue
+			}
+
+			overrides = append(overrides, Override{
+				Key:    setting.Key,
+				Value:  strings.TrimPrefix(pair, prefix),
+				Origin: fmt.Sprintf("environment variable %v", setting.Env()),
+			})
+		}
+	}
+
+	return overrides
+}
+
+// Set applies a raw setting value.
+func (o *Index) Set(key string, value string, origin string) error {
+	parseBool := func() (bool, error) {
+		b, err := strconv.ParseBool(value)
+
+		if err != nil {
+			return false, fmt.Errorf("%v: invalid %v: %v", origin, key, value)
+		}
+
+		return b, nil
+	}
+
+	parseInt := func() (int, error) {
+		i, err := strconv.Atoi(value)
+
+		if err != nil {
+			return 0, fmt.Errorf("%v: invalid %v: %v", origin, key, value)
+		}
+
+		return i, nil
+	}
+
+	var err error
+
+	switch key {
+	case "debug":
+		o.Debug, err = parseBool()
+	case "quiet":
+		o.Quiet, err = parseBool()
+	case "lead_months":
+		o.LeadMonths, err = parseInt()
+	case "cache_dir":
+		o.CacheDir = value
+	case "offline":
+		o.Offline, err = parseBool()
+	case "format":
+		o.Format = value
+	case "fail_threshold":
+		o.FailThreshold, err = parseInt()
+	case "query_timeout":
+		if err2 := o.QueryTimeout.UnmarshalText([]byte(value)); err2 != nil {
+			err = fmt.Errorf("%v: invalid %v: %v", origin, key, value)
+		}
+	case "scan_timeout":
+		if err2 := o.ScanTimeout.UnmarshalText([]byte(value)); err2 != nil {
+			err = fmt.Errorf("%v: invalid %v: %v", origin, key, value)
+		}
+	case "jobs":
+		o.Jobs, err = parseInt()
+	case "all_installs":
+		o.AllInstalls, err = parseBool()
+	case "packages":
+		o.Packages, err = parseBool()
+	case "unpinned_tags":
+		o.UnpinnedTags, err = parseBool()
+	case "resolve_images":
+		o.ResolveImages, err = parseBool()
+	default:
+		return fmt.Errorf("%v: unknown setting: %v", origin, key)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	o.setOrigin(key, origin)
+	return nil
+}
+
+// setOrigin records where a setting value came from.
+func (o *Index) setOrigin(key string, origin string) {
+	if o.origins == nil {
+		o.origins = make(map[string]string)
+	}
+
+	o.origins[key] = origin
+}
+
+// Get formats an effective setting value.
+func (o Index) Get(key string) string {
+	switch key {
+	case "debug":
+		return strconv.FormatBool(o.Debug)
+	case "quiet":
+		return strconv.FormatBool(o.Quiet)
+	case "lead_months":
+		return strconv.Itoa(o.LeadMonths)
+	case "cache_dir":
+		return o.CacheDir
+	case "offline":
+		return strconv.FormatBool(o.Offline)
+	case "format":
+		return o.Format
+	case "fail_threshold":
+		return strconv.Itoa(o.FailThreshold)
+	case "query_timeout":
+		return o.QueryTimeout.String()
+	case "scan_timeout":
+		return o.ScanTimeout.String()
+	case "jobs":
+		return strconv.Itoa(o.Jobs)
+	case "all_installs":
+		return strconv.FormatBool(o.AllInstalls)
+	case "packages":
+		return strconv.FormatBool(o.Packages)
+	case "unpinned_tags":
+		return strconv.FormatBool(o.UnpinnedTags)
+	case "resolve_images":
+		return strconv.FormatBool(o.ResolveImages)
+	default:
+		return ""
+	}
+}
+
+// Origin describes where an effective setting value came from.
+func (o Index) Origin(key string) string {
+	if origin, ok := o.origins[key]; ok {
+		return origin
+	}
+
+	return OriginDefault
+}
+
+// LogSettings logs the effective setting values and their origins.
+func (o Index) LogSettings() {
+	for _, setting := range Settings {
+		log.Printf("setting %v: %v (from %v)\n", setting.Key, o.Get(setting.Key), o.Origin(setting.Key))
+	}
+}
+
+// ValidateSettings ensures setting data integrity.
+func (o Index) ValidateSettings() error {
+	if o.Format != FormatText && o.Format != FormatJSON {
+		return fmt.Errorf("unknown format: %v", o.Format)
+	}
+
+	if o.FailThreshold < 0 {
+		return fmt.Errorf("negative fail_threshold: %v", o.FailThreshold)
+	}
+
+	if o.QueryTimeout < 0 {
+		return fmt.Errorf("negative query_timeout: %v", o.QueryTimeout)
+	}
+
+	if o.ScanTimeout < 0 {
+		return fmt.Errorf("negative scan_timeout: %v", o.ScanTimeout)
+	}
+
+	if o.Jobs < 1 {
+		return fmt.Errorf("jobs must be positive: %v", o.Jobs)
+	}
+
+	return nil
+}
+
+// CachePolicy models cache handling for remote data.
+type CachePolicy struct {
+	// Dir denotes the cicada metadata directory.
+	Dir string
+
+	// Update forces cached remote data to refresh.
+	Update bool
+
+	// Offline prevents network access.
+	Offline bool
+}
+
+// ResolveCacheDir locates a cache directory setting,
+// relative to the project directory.
+func ResolveCacheDir(projectDir string, cacheDir string) string {
+	if cacheDir == "" {
+		return filepath.Join(projectDir, IndexCacheRoot)
+	}
+
+	if filepath.IsAbs(cacheDir) {
+		return cacheDir
+	}
+
+	return filepath.Join(projectDir, cacheDir)
+}
+
+// Refresh reports whether a cached resource should be downloaded.
+//
+// In offline mode, missing caches are an error.
+func (o CachePolicy) Refresh(pth string) (bool, error) {
+	_, err := os.Stat(pth)
+	missing := os.IsNotExist(err)
+
+	if o.Offline {
+		if missing {
+			return false, fmt.Errorf("offline mode: missing cached data: %v", pth)
+		}
+
+		return false, nil
+	}
+
+	return o.Update || missing, nil
+}
@@ -0,0 +1,75 @@
+package cicada_test
+
+import (
+	"github.com/mcandre/cicada"
+
+	"testing"
+)
+
+func TestSettingLayers(t *testing.T) {
+	index, err := cicada.NewDefaultIndex()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	overrides := cicada.EnvironmentOverrides([]string{
+		"HOME=/home/cicada",
+		"CICADA_LEAD_MONTHS=6",
+		"CICADA_FORMAT=json",
+	})
+
+	overrides = append(overrides, cicada.Override{Key: "lead_months", Value: "2", Origin: "flag -lead-months"})
+
+	for _, override := range overrides {
+		if err := index.Set(override.Key, override.Value, override.Origin); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if index.LeadMonths != 2 || index.Origin("lead_months") != "flag -lead-months" {
+		t.Errorf("Expected flag to take precedence over environment, got: %v from %v", index.LeadMonths, index.Origin("lead_months"))
+	}
+
+	if index.Format != cicada.FormatJSON || index.Origin("format") != "environment variable CICADA_FORMAT" {
+		t.Errorf("Expected environment format, got: %v from %v", index.Format, index.Origin("format"))
+	}
+
+	if index.Origin("fail_threshold") != cicada.OriginDefault {
+		t.Errorf("Expected default fail_threshold origin, got: %v", index.Origin("fail_threshold"))
+	}
+
+	if err := index.Set("offline", "maybe", "test"); err == nil {
+		t.Errorf("Expected invalid boolean to be rejected")
+	}
+}
+
+func TestSettingPackages(t *testing.T) {
+	index, err := cicada.NewDefaultIndex()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !index.Packages || index.Quiet {
+		t.Errorf("Expected package scanning by default, independent of quiet")
+	}
+
+	if err := index.Set("quiet", "true", "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !index.Packages {
+		t.Errorf("Expected quiet to leave package scanning enabled")
+	}
+
+	for _, override := range cicada.EnvironmentOverrides([]string{"CICADA_PACKAGES=false"}) {
+		if err := index.Set(override.Key, override.Value, override.Origin); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if index.Packages || index.Get("packages") != "false" {
+		t.Errorf("Expected CICADA_PACKAGES to disable package scanning, got: %v", index.Get("packages"))
+	}
+}
@@ -3,18 +3,46 @@ package cicada
 import (
 	"gopkg.in/yaml.v3"
 
-	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 )
 
-// VersionQuery models commands for extracting software component version information.
+// VersionQuerySourceCommand identifies version queries that execute commands.
+const VersionQuerySourceCommand = "command"
+
+// VersionQuerySourceFile identifies version queries that read files.
+const VersionQuerySourceFile = "file"
+
+// VersionQuerySourceEnv identifies version queries that read environment variables.
+const VersionQuerySourceEnv = "env"
+
+// VersionQuery models instructions for extracting software component version information.
+//
+// Exactly one of Command, File, or Env is specified.
 type VersionQuery struct {
 	// Command denotes an exec-like command line instruction.
 	//
 	// Command output is always right trimmed.
-	Command []string `yaml:"command"`
+	Command []string `json:"command,omitempty" toml:"command,omitempty" yaml:"command,omitempty"`
+
+	// File denotes a path or glob to read.
+	//
+	// When a glob matches multiple files,
+	// the lexically first match is read.
+	File string `json:"file,omitempty" toml:"file,omitempty" yaml:"file,omitempty"`
+
+	// Env denotes an environment variable name to read.
+	Env string `json:"env,omitempty" toml:"env,omitempty" yaml:"env,omitempty"`
 
 	// Pattern denotes an optional expression for
 	// capturing version strings within
@@ -25,28 +53,77 @@ type VersionQuery struct {
 	// is treated as a semver version string.
 	//
 	// (default: nil)
-	Pattern *regexp.Regexp `yaml:"pattern,omitempty"`
+	Pattern *regexp.Regexp `json:"pattern,omitempty" toml:"pattern,omitempty" yaml:"pattern,omitempty"`
+
+	// Timeout bounds the command execution time.
+	//
+	// File and Env queries ignore Timeout.
+	//
+	// Zero indicates the index query_timeout.
+	//
+	// (default: 0)
+	Timeout Duration `json:"timeout,omitempty" toml:"timeout,omitempty" yaml:"timeout,omitempty"`
 }
 
-// MarshalYAML encodes version queries.
-func (o VersionQuery) MarshalYAML() (interface{}, error) {
-	type VersionQueryAlias struct {
-		Command []string `yaml:"command"`
-		Pattern *string  `yaml:"pattern,omitempty"`
-	}
+// VersionQueryAlias models the serialized form of version queries.
+type VersionQueryAlias struct {
+	Command []string `json:"command,omitempty" toml:"command,omitempty" yaml:"command,omitempty"`
+	File    string   `json:"file,omitempty" toml:"file,omitempty" yaml:"file,omitempty"`
+	Env     string   `json:"env,omitempty" toml:"env,omitempty" yaml:"env,omitempty"`
+	Pattern *string  `json:"pattern,omitempty" toml:"pattern,omitempty" yaml:"pattern,omitempty"`
+	Timeout Duration `json:"timeout,omitempty" toml:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
 
+// Alias converts version queries to their serialized form.
+func (o VersionQuery) Alias() VersionQueryAlias {
 	var aux VersionQueryAlias
 	aux.Command = o.Command
-	patternString := o.Pattern.String()
-	aux.Pattern = &patternString
-	return aux, nil
+	aux.File = o.File
+	aux.Env = o.Env
+	aux.Timeout = o.Timeout
+
+	if o.Pattern != nil {
+		patternString := o.Pattern.String()
+		aux.Pattern = &patternString
+	}
+
+	return aux
+}
+
+// VersionQuery converts serialized version queries.
+func (o VersionQueryAlias) VersionQuery() (*VersionQuery, error) {
+	var query VersionQuery
+
+	if o.Pattern != nil {
+		patternString := *o.Pattern
+
+		if patternString != "" {
+			pattern, err := regexp.Compile(patternString)
+
+			if err != nil {
+				return nil, err
+			}
+
+			query.Pattern = pattern
+		}
+	}
+
+	query.Command = o.Command
+	query.File = o.File
+	query.Env = o.Env
+	query.Timeout = o.Timeout
+	return &query, nil
+}
+
+// MarshalYAML encodes version queries.
+func (o VersionQuery) MarshalYAML() (interface{}, error) {
+	return o.Alias(), nil
 }
 
 // UnmarshalYAML decodes version queries.
 func (o *VersionQuery) UnmarshalYAML(value *yaml.Node) error {
-	type VersionQueryAlias struct {
-		Command []string `yaml:"command"`
-		Pattern *string  `yaml:"pattern,omitempty"`
+	if err := CheckYAMLKeys(value, "command", "file", "env", "pattern", "timeout"); err != nil {
+		return err
 	}
 
 	var aux VersionQueryAlias
@@ -55,58 +132,329 @@ func (o *VersionQuery) UnmarshalYAML(value *yaml.Node) error {
 		return err
 	}
 
-	if aux.Pattern != nil {
-		patternString := *aux.Pattern
+	query, err := aux.VersionQuery()
 
-		if patternString != "" {
-			pattern, err := regexp.Compile(patternString)
+	if err != nil {
+		return err
+	}
 
-			if err != nil {
-				return err
-			}
+	*o = *query
+	return nil
+}
 
-			o.Pattern = pattern
-		}
+// MarshalJSON encodes version queries.
+func (o VersionQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(o.Alias())
+}
+
+// UnmarshalJSON decodes version queries.
+func (o *VersionQuery) UnmarshalJSON(data []byte) error {
+	var aux VersionQueryAlias
+
+	if err := DecodeJSONStrict(data, &aux); err != nil {
+		return err
+	}
+
+	query, err := aux.VersionQuery()
+
+	if err != nil {
+		return err
 	}
 
-	o.Command = aux.Command
+	*o = *query
 	return nil
 }
 
+// MarshalTOML encodes version queries, as inline tables.
+func (o VersionQuery) MarshalTOML() ([]byte, error) {
+	return MarshalTOMLInline(o.Alias())
+}
+
+// UnmarshalTOML decodes version queries.
+func (o *VersionQuery) UnmarshalTOML(data interface{}) error {
+	dataJSON, err := json.Marshal(data)
+
+	if err != nil {
+		return err
+	}
+
+	return o.UnmarshalJSON(dataJSON)
+}
+
+// Source identifies the kind of version query:
+// "command", "file", or "env".
+//
+// Blank indicates an empty query.
+func (o VersionQuery) Source() string {
+	switch {
+	case len(o.Command) != 0:
+		return VersionQuerySourceCommand
+	case o.File != "":
+		return VersionQuerySourceFile
+	case o.Env != "":
+		return VersionQuerySourceEnv
+	default:
+		return ""
+	}
+}
+
+// Validate ensures data integrity.
+func (o VersionQuery) Validate() error {
+	var sources int
+
+	if len(o.Command) != 0 {
+		sources++
+	}
+
+	if o.File != "" {
+		sources++
+	}
+
+	if o.Env != "" {
+		sources++
+	}
+
+	switch sources {
+	case 0:
+		return fmt.Errorf("empty version query")
+	case 1:
+	default:
+		return fmt.Errorf("version query specifies more than one of command, file, env")
+	}
+
+	if o.Pattern != nil && o.Pattern.SubexpIndex("Version") < 0 {
+		return fmt.Errorf("version query pattern lacks a (?P<Version>...) capture group: %v", o.Pattern)
+	}
+
+	return nil
+}
+
+// String renders version queries, for diagnostics.
+func (o VersionQuery) String() string {
+	switch o.Source() {
+	case VersionQuerySourceCommand:
+		return strings.Join(o.Command, " ")
+	case VersionQuerySourceFile:
+		return fmt.Sprintf("file %v", o.File)
+	case VersionQuerySourceEnv:
+		return fmt.Sprintf("env %v", o.Env)
+	default:
+		return ""
+	}
+}
+
+// Locate resolves the query executable path,
+// file path, or environment variable name.
+//
+// An error indicates the component is not installed.
+func (o VersionQuery) Locate() (*string, error) {
+	switch o.Source() {
+	case VersionQuerySourceCommand:
+		executablePath, err := exec.LookPath(o.Command[0])
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &executablePath, nil
+	case VersionQuerySourceFile:
+		matches, err := filepath.Glob(o.File)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no such file: %v", o.File)
+		}
+
+		return &matches[0], nil
+	case VersionQuerySourceEnv:
+		if _, ok := os.LookupEnv(o.Env); !ok {
+			return nil, fmt.Errorf("unset environment variable: %v", o.Env)
+		}
+
+		return &o.Env, nil
+	default:
+		return nil, fmt.Errorf("empty version query")
+	}
+}
+
+// ReadFS retrieves software component versions from file queries,
+// within a root filesystem.
+//
+// Absolute file paths resolve relative to the root.
+func (o VersionQuery) ReadFS(fsys fs.FS) (*QueryResult, error) {
+	if o.Source() != VersionQuerySourceFile {
+		return nil, fmt.Errorf("version query does not read a file: %v", o)
+	}
+
+	var result QueryResult
+	result.Query = o.String()
+	matches, err := fs.Glob(fsys, strings.TrimPrefix(o.File, "/"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(matches) == 0 {
+		result.Status = QueryStatusNotInstalled
+		return &result, nil
+	}
+
+	result.Location = "/" + matches[0]
+	content, err := fs.ReadFile(fsys, matches[0])
+
+	if err != nil {
+		result.Status = QueryStatusFailed
+		result.Stderr = Excerpt(err.Error())
+		return &result, nil
+	}
+
+	result.Output = string(content)
+	result.Parse(o.Pattern)
+	return &result, nil
+}
+
+// ReadEnv retrieves software component versions from env queries,
+// within an environment of KEY=value entries.
+func (o VersionQuery) ReadEnv(environ []string) (*QueryResult, error) {
+	if o.Source() != VersionQuerySourceEnv {
+		return nil, fmt.Errorf("version query does not read an environment variable: %v", o)
+	}
+
+	var result QueryResult
+	result.Query = o.String()
+	var found bool
+
+	for _, entry := range environ {
+		key, value, ok := strings.Cut(entry, "=")
+
+		if ok && key == o.Env {
+			found = true
+			result.Output = value
+		}
+	}
+
+	if !found {
+		result.Status = QueryStatusNotInstalled
+		return &result, nil
+	}
+
+	result.Location = o.Env
+	result.Parse(o.Pattern)
+	return &result, nil
+}
+
 // Execute retrieves software component versions.
-func (o VersionQuery) Execute() (*string, error) {
-	command, args := o.Command[0], o.Command[1:]
-	cmd := exec.Command(command, args...)
-	outputBytes, err := cmd.Output()
+//
+// timeout applies when a command query does not specify its own timeout.
+// Zero indicates no timeout.
+//
+// Failures to run the query or to identify a version
+// are classified in the result status, rather than returned as errors.
+func (o VersionQuery) Execute(ctx context.Context, timeout Duration) (*QueryResult, error) {
+	var result QueryResult
+	result.Query = o.String()
+	locationP, err := o.Locate()
 
 	if err != nil {
-		return nil, nil
+		result.Status = QueryStatusNotInstalled
+		return &result, nil
 	}
 
-	outputString := string(outputBytes)
-	versionString := strings.TrimRight(outputString, "\r\n")
+	result.Location = *locationP
 
-	if o.Pattern != nil {
-		scanner := bufio.NewScanner(strings.NewReader(outputString))
+	switch o.Source() {
+	case VersionQuerySourceFile:
+		content, err2 := os.ReadFile(result.Location)
 
-		var foundVersion bool
+		if err2 != nil {
+			result.Status = QueryStatusFailed
+			result.Stderr = Excerpt(err2.Error())
+			return &result, nil
+		}
 
-		for scanner.Scan() {
-			line := scanner.Text()
-			match := o.Pattern.FindStringSubmatch(line)
-			versionIndex := o.Pattern.SubexpIndex("Version")
+		result.Output = string(content)
+	case VersionQuerySourceEnv:
+		result.Output = os.Getenv(o.Env)
+	default:
+		if err2 := o.Run(ctx, timeout, &result); err2 != nil {
+			return nil, err2
+		}
 
-			if len(match) > versionIndex {
-				foundVersion = true
-				versionString = match[versionIndex]
-				break
-			}
+		if result.Status != "" {
+			return &result, nil
 		}
+	}
+
+	result.Parse(o.Pattern)
+	return &result, nil
+}
+
+// Run executes command queries, recording output in the result.
+//
+// Timeouts, non-zero exit statuses, and other command failures
+// are recorded in the result status.
+// Errors indicate an expired or canceled context.
+func (o VersionQuery) Run(ctx context.Context, timeout Duration, result *QueryResult) error {
+	// Abort once the scan as a whole expires.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	parent := ctx
 
-		if !foundVersion {
-			return nil, nil
+	if o.Timeout != 0 {
+		timeout = o.Timeout
+	}
+
+	result.Timeout = timeout
+
+	if timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout))
+		defer cancel()
+	}
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	command, args := o.Command[0], o.Command[1:]
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	// Do not wait indefinitely on grandchild processes holding output pipes open.
+	cmd.WaitDelay = time.Second
+	err := cmd.Run()
+	result.Output = stdout.String()
+	result.Stderr = Excerpt(stderr.String())
+
+	if err2 := parent.Err(); err2 != nil {
+		return err2
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		result.Status = QueryStatusTimedOut
+		return nil
+	}
+
+	if err != nil {
+		var exitErr *exec.ExitError
+
+		result.Status = QueryStatusFailed
+
+		if !errors.As(err, &exitErr) {
+			// Such as exec.ErrWaitDelay, from a child holding output pipes open.
+			if result.Stderr == "" {
+				result.Stderr = Excerpt(err.Error())
+			}
+
+			return nil
 		}
+
+		result.ExitCode = exitErr.ExitCode()
 	}
 
-	return &versionString, nil
+	return nil
 }
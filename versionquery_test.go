@@ -1,12 +1,21 @@
 package cicada_test
 
 import (
+	"github.com/BurntSushi/toml"
 	"github.com/mcandre/cicada"
 	"gopkg.in/yaml.v3"
 
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"reflect"
 	"regexp"
 	"testing"
+	"time"
 )
 
 func TestVersionQueryYAMLCodec(t *testing.T) {
@@ -30,3 +39,225 @@ func TestVersionQueryYAMLCodec(t *testing.T) {
 		t.Errorf("Expected decoded query2: %v to equal original query: %v", query2, query)
 	}
 }
+
+func TestVersionQueryJSONCodec(t *testing.T) {
+	query := cicada.VersionQuery{
+		Command: []string{"lsb_release", "-r"},
+		Pattern: regexp.MustCompile(`^Release:\s+(?P<Version>[0-9\.]+)$`),
+	}
+
+	queryJSON, err := json.Marshal(query)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var query2 cicada.VersionQuery
+	if err := json.Unmarshal(queryJSON, &query2); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(query2, query) {
+		t.Errorf("Expected decoded query2: %v to equal original query: %v", query2, query)
+	}
+}
+
+func TestVersionQueryTOMLCodec(t *testing.T) {
+	queries := map[string]cicada.VersionQuery{
+		"ubuntu": {
+			Command: []string{"lsb_release", "-r"},
+			Pattern: regexp.MustCompile(`^Release:\s+(?P<Version>[0-9\.]+)$`),
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(queries); err != nil {
+		t.Fatal(err)
+	}
+
+	var queries2 map[string]cicada.VersionQuery
+	if err := cicada.DecodeTOMLStrict(buf.Bytes(), &queries2); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(queries2, queries) {
+		t.Errorf("Expected decoded queries2: %v to equal original queries: %v", queries2, queries)
+	}
+}
+
+func TestVersionQueryTimeout(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not available")
+	}
+
+	query := cicada.VersionQuery{
+		Command: []string{"sleep", "10"},
+		Timeout: cicada.Duration(100 * time.Millisecond),
+	}
+
+	start := time.Now()
+
+	result, err := query.Execute(context.Background(), cicada.DefaultQueryTimeout)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Status != cicada.QueryStatusTimedOut {
+		t.Errorf("Expected query timeout, got: %v", result.Status)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected query to stop promptly, took: %v", elapsed)
+	}
+}
+
+func TestVersionQueryWaitDelay(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	query := cicada.VersionQuery{
+		Command: []string{"sh", "-c", "sleep 5 & echo 1.2.3"},
+	}
+
+	result, err := query.Execute(context.Background(), cicada.DefaultQueryTimeout)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Status != cicada.QueryStatusFailed {
+		t.Errorf("Expected failed query for child holding stdout open, got: %v", result.Status)
+	}
+}
+
+func TestVersionQueryScanExpired(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	query := cicada.VersionQuery{
+		Command: []string{"sh", "-c", "echo 1.2.3"},
+	}
+
+	if _, err := query.Execute(ctx, cicada.DefaultQueryTimeout); err == nil {
+		t.Errorf("Expected error for expired scan context")
+	}
+}
+
+func TestVersionQueryScanDeadline(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not available")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	query := cicada.VersionQuery{
+		Command: []string{"sleep", "10"},
+		Timeout: cicada.Duration(time.Minute),
+	}
+
+	start := time.Now()
+
+	if _, err := query.Execute(ctx, cicada.DefaultQueryTimeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected scan deadline error, got: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected query to stop promptly, took: %v", elapsed)
+	}
+}
+
+func TestQueryResultParse(t *testing.T) {
+	pattern := regexp.MustCompile(`widget (?P<Version>\S+)`)
+
+	result := cicada.QueryResult{Output: "widget 1.2.3\n"}
+	result.Parse(pattern)
+
+	if result.Status != cicada.QueryStatusFound || result.Version.String() != "1.2.3" {
+		t.Errorf("Expected found 1.2.3, got: %v %v", result.Status, result.Version)
+	}
+
+	result = cicada.QueryResult{Output: "gadget 1.2.3\n"}
+	result.Parse(pattern)
+
+	if result.Status != cicada.QueryStatusNoMatch {
+		t.Errorf("Expected no match, got: %v", result.Status)
+	}
+
+	result = cicada.QueryResult{Output: "widget banana\n"}
+	result.Parse(pattern)
+
+	if result.Status != cicada.QueryStatusUnparseable {
+		t.Errorf("Expected unparseable, got: %v", result.Status)
+	}
+}
+
+func TestVersionQueryFile(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "widget-release"), []byte("3.19.1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	query := cicada.VersionQuery{File: filepath.Join(dir, "widget-*")}
+	result, err := query.Execute(context.Background(), cicada.DefaultQueryTimeout)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Status != cicada.QueryStatusFound || result.Version.String() != "3.19.1" {
+		t.Errorf("Expected found 3.19.1, got: %v %v", result.Status, result.Version)
+	}
+
+	query = cicada.VersionQuery{File: filepath.Join(dir, "gadget-release")}
+	result, err = query.Execute(context.Background(), cicada.DefaultQueryTimeout)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Status != cicada.QueryStatusNotInstalled {
+		t.Errorf("Expected not installed, got: %v", result.Status)
+	}
+}
+
+func TestVersionQueryEnv(t *testing.T) {
+	t.Setenv("CICADA_TEST_WIDGET_VERSION", "widget 2.1.0")
+
+	query := cicada.VersionQuery{
+		Env:     "CICADA_TEST_WIDGET_VERSION",
+		Pattern: regexp.MustCompile(`^widget (?P<Version>\S+)$`),
+	}
+
+	result, err := query.Execute(context.Background(), cicada.DefaultQueryTimeout)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Status != cicada.QueryStatusFound || result.Version.String() != "2.1.0" {
+		t.Errorf("Expected found 2.1.0, got: %v %v", result.Status, result.Version)
+	}
+}
+
+func TestVersionQueryValidateSources(t *testing.T) {
+	query := cicada.VersionQuery{
+		Command: []string{"widget", "--version"},
+		Env:     "WIDGET_VERSION",
+	}
+
+	if err := query.Validate(); err == nil {
+		t.Errorf("Expected error for multiple version query sources")
+	}
+
+	if err := (cicada.VersionQuery{}).Validate(); err == nil {
+		t.Errorf("Expected error for empty version query")
+	}
+}